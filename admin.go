@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/handler"
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	api "github.com/keptn/go-utils/pkg/api/utils"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	logger "github.com/sirupsen/logrus"
+)
+
+// the source of the events sent to keptn
+const adminEventSource = "splunk-service"
+
+// options of the alerts subcommand
+type alertsOptions struct {
+	Connection    string
+	KeptnEndpoint string
+	KeptnAPIToken string
+	Project       string
+	Service       string
+	Yes           bool
+	splunkClient  *splunk.SplunkClient
+	keptnAPI      *api.APISet
+	out           io.Writer
+}
+
+// Runs the alerts subcommand, administrating the alerts created by the service in splunk
+//
+//	splunk-service alerts list|history|prune|reconfigure [flags]
+func runAlerts(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected one of the commands list, history, prune or reconfigure")
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("alerts "+command, flag.ContinueOnError)
+	flags.SetOutput(w)
+	options := alertsOptions{out: w}
+	flags.StringVar(&options.Connection, "connection", "", "connection of SP_CONNECTIONS_FILE, the splunk of the environment by default")
+	flags.StringVar(&options.KeptnEndpoint, "keptn-endpoint", os.Getenv("KEPTN_ENDPOINT"), "url of the keptn api, needed by prune and reconfigure")
+	flags.StringVar(&options.KeptnAPIToken, "keptn-api-token", os.Getenv("KEPTN_API_TOKEN"), "token of the keptn api")
+	flags.StringVar(&options.Project, "project", "", "project of the alerts")
+	flags.StringVar(&options.Service, "service", "", "service of the alerts")
+	flags.BoolVar(&options.Yes, "yes", false, "remove the orphaned alerts listed by prune, which only lists them otherwise")
+
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	// only the result of the command is written
+	logger.SetLevel(logger.WarnLevel)
+
+	switch command {
+	case "list", "history", "prune":
+		options.splunkClient, err = connectSplunk(options.Connection)
+		if err != nil {
+			return err
+		}
+	case "reconfigure":
+		if options.Project == "" || options.Service == "" {
+			return fmt.Errorf("the project and the service are required")
+		}
+	default:
+		return fmt.Errorf("unknown command %s, expected one of list, history, prune or reconfigure", command)
+	}
+
+	if command == "prune" || command == "reconfigure" {
+		if options.KeptnEndpoint == "" {
+			return fmt.Errorf("the url of the keptn api is required, set --keptn-endpoint or KEPTN_ENDPOINT")
+		}
+		options.keptnAPI, err = api.New(options.KeptnEndpoint, api.WithAuthToken(options.KeptnAPIToken))
+		if err != nil {
+			return fmt.Errorf("failed to connect to keptn: %w", err)
+		}
+	}
+
+	switch command {
+	case "list":
+		return listAlerts(options)
+	case "history":
+		return listFiredAlerts(options)
+	case "prune":
+		return pruneAlerts(options)
+	default:
+		return reconfigureMonitoring(options)
+	}
+}
+
+// Connects to the splunk of the environment, or to the named connection of SP_CONNECTIONS_FILE
+func connectSplunk(connection string) (*splunk.SplunkClient, error) {
+
+	var client *splunk.SplunkClient
+	splunkCreds, err := utils.GetSplunkCredentials(env)
+	switch {
+	case err == nil:
+		client, err = utils.NewSplunkClient(*splunkCreds, env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to splunk: %w", err)
+		}
+	case env.SplunkConnectionsFile == "":
+		return nil, fmt.Errorf("failed to get splunk credentials: %w", err)
+	}
+
+	if env.SplunkConnectionsFile == "" {
+		if connection != "" {
+			return nil, fmt.Errorf("no connection %s, SP_CONNECTIONS_FILE is not set", connection)
+		}
+		return client, nil
+	}
+	splunkConnections, err := utils.LoadSplunkConnections(env.SplunkConnectionsFile, env, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load splunk connections: %w", err)
+	}
+	if connection != "" {
+		return splunkConnections.Get(connection)
+	}
+	if splunkConnections.Default() == nil {
+		return nil, fmt.Errorf("no default splunk connection defined in %s", env.SplunkConnectionsFile)
+	}
+	return splunkConnections.Default(), nil
+}
+
+// Writes the keptn alerts of the project and the service
+func listAlerts(options alertsOptions) error {
+	keptnAlerts, err := handler.ListKeptnAlerts(options.splunkClient)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(options.out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "PROJECT\tSTAGE\tSERVICE\tSLI\tCRITERIA\tNAME")
+	for _, alert := range keptnAlerts {
+		if options.matches(alert) {
+			_, _ = fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", alert.Project, alert.Stage, alert.Service, alert.SLI, alert.Criteria, alert.Name)
+		}
+	}
+	return table.Flush()
+}
+
+// Writes the triggerings of the keptn alerts of the project and the service, the latest first
+func listFiredAlerts(options alertsOptions) error {
+	firedAlerts, err := handler.ListFiredAlerts(options.splunkClient)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(options.out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "TRIGGERED\tPROJECT\tSTAGE\tSERVICE\tSLI\tCRITERIA\tSID")
+	for _, fired := range firedAlerts {
+		if options.matches(fired.KeptnAlert) {
+			_, _ = fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", fired.TriggerTime.Format(time.RFC3339), fired.Project, fired.Stage, fired.Service, fired.SLI, fired.Criteria, fired.Sid)
+		}
+	}
+	return table.Flush()
+}
+
+// Removes the keptn alerts whose project or service no longer exists in keptn, only lists them unless confirmed with --yes
+func pruneAlerts(options alertsOptions) error {
+	keptnAlerts, err := handler.ListKeptnAlerts(options.splunkClient)
+	if err != nil {
+		return err
+	}
+	projects, err := options.keptnAPI.ProjectsV1().GetAllProjects()
+	if err != nil {
+		return fmt.Errorf("failed to get the keptn projects: %w", err)
+	}
+	// an empty keptn is more likely a wrong endpoint or token than a keptn without any project
+	if len(projects) == 0 {
+		return fmt.Errorf("keptn returned no project, refusing to prune all the keptn alerts")
+	}
+
+	orphaned := handler.OrphanedAlerts(keptnAlerts, handler.KeptnServices(projects))
+	for _, alert := range orphaned {
+		_, _ = fmt.Fprintln(options.out, alert.Name)
+	}
+	if !options.Yes {
+		_, _ = fmt.Fprintf(options.out, "%d orphaned alerts, run again with --yes to remove them\n", len(orphaned))
+		return nil
+	}
+	err = handler.RemoveAlerts(options.splunkClient, orphaned)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(options.out, "%d orphaned alerts removed\n", len(orphaned))
+	return nil
+}
+
+// Triggers the configure-monitoring of the service through keptn, which recreates its alerts
+func reconfigureMonitoring(options alertsOptions) error {
+	data := keptnv2.ConfigureMonitoringTriggeredEventData{
+		EventData: keptnv2.EventData{Project: options.Project, Service: options.Service},
+	}
+	data.ConfigureMonitoring.Type = "splunk"
+
+	event := keptnv2.KeptnEvent(keptnv2.GetTriggeredEventType(keptnv2.ConfigureMonitoringTaskName), adminEventSource, data).KeptnContextExtendedCE
+	eventContext, errModel := options.keptnAPI.APIV1().SendEvent(event)
+	if errModel != nil {
+		return fmt.Errorf("failed to send the configure-monitoring event: %s", errModel.GetMessage())
+	}
+	keptnContext := ""
+	if eventContext != nil && eventContext.KeptnContext != nil {
+		keptnContext = *eventContext.KeptnContext
+	}
+	_, _ = fmt.Fprintf(options.out, "configure-monitoring triggered for the service %s of the project %s, keptn context %s\n", options.Service, options.Project, keptnContext)
+	return nil
+}
+
+// check if the alert is of the project and the service of the options, when they are set
+func (options alertsOptions) matches(alert handler.KeptnAlert) bool {
+	return (options.Project == "" || alert.Project == options.Project) && (options.Service == "" || alert.Service == options.Service)
+}
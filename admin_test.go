@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	splunktest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+// Tests that the keptn alerts of the project are listed with their parsed names and that the commands are checked
+func TestRunAlerts(t *testing.T) {
+	splunkResponses := []map[string]interface{}{{
+		splunktest.GetAlertsNames: `{"entry":[
+			{"name":"podtatohead,hardening,helloservice,number_of_errors,>=100,keptn"},
+			{"name":"fulltour,production,carts,latency,>500,keptn"},
+			{"name":"Errors in the last hour"}
+		]}`,
+	}}
+	splunkServer := splunktest.MultitpleMockRequest(splunkResponses, true)
+	defer splunkServer.Close()
+
+	savedEnv := env
+	defer func() { env = savedEnv }()
+	env.SplunkHost = splunktest.GetTestHostname(splunkServer)
+	env.SplunkPort = splunktest.GetTestPort(splunkServer)
+	env.SplunkApiToken = "apiToken"
+	env.SplunkSkipSSLVerify = true
+
+	var output bytes.Buffer
+	err := runAlerts([]string{"list", "--project", "podtatohead"}, &output)
+	if err != nil {
+		t.Fatalf("Error while listing the alerts : %v", err)
+	}
+	if !strings.Contains(output.String(), "podtatohead  hardening  helloservice  number_of_errors  >=100") ||
+		strings.Contains(output.String(), "fulltour") || strings.Contains(output.String(), "Errors in the last hour") {
+		t.Fatalf("Expected the keptn alerts of the project only but got :\n%s", output.String())
+	}
+
+	for _, args := range [][]string{{}, {"unknown"}, {"reconfigure", "--project", "podtatohead"}, {"prune", "--keptn-endpoint", ""}} {
+		err = runAlerts(args, &output)
+		if err == nil {
+			t.Errorf("Expected an error for the arguments %v", args)
+		}
+	}
+}
+
+// Tests that prune only lists the orphaned alerts without --yes and refuses to run when keptn has no project
+func TestPruneAlerts(t *testing.T) {
+	splunkResponses := []map[string]interface{}{{
+		splunktest.GetAlertsNames: `{"entry":[
+			{"name":"podtatohead,hardening,helloservice,number_of_errors,>=100,keptn"},
+			{"name":"fulltour,production,carts,latency,>500,keptn"}
+		]}`,
+	}}
+	splunkServer := splunktest.MultitpleMockRequest(splunkResponses, true)
+	defer splunkServer.Close()
+
+	projects := `{"projects":[{"projectName":"podtatohead","stages":[{"stageName":"hardening","services":[{"serviceName":"helloservice"}]}]}]}`
+	keptnServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(projects))
+	}))
+	defer keptnServer.Close()
+
+	savedEnv := env
+	defer func() { env = savedEnv }()
+	env.SplunkHost = splunktest.GetTestHostname(splunkServer)
+	env.SplunkPort = splunktest.GetTestPort(splunkServer)
+	env.SplunkApiToken = "apiToken"
+	env.SplunkSkipSSLVerify = true
+
+	var output bytes.Buffer
+	err := runAlerts([]string{"prune", "--keptn-endpoint", keptnServer.URL}, &output)
+	if err != nil {
+		t.Fatalf("Error while listing the orphaned alerts : %v", err)
+	}
+	if !strings.Contains(output.String(), "fulltour,production,carts,latency,>500,keptn") || strings.Contains(output.String(), "podtatohead") ||
+		!strings.Contains(output.String(), "1 orphaned alerts, run again with --yes") {
+		t.Fatalf("Expected the orphaned alert to be listed only but got :\n%s", output.String())
+	}
+
+	projects = `{"projects":[]}`
+	err = runAlerts([]string{"prune", "--yes", "--keptn-endpoint", keptnServer.URL}, &output)
+	if err == nil {
+		t.Fatal("Expected prune to be refused when keptn returns no project")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	splunkalerts "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/alerts"
@@ -219,14 +220,39 @@ func isTestKeptn(i interface{}) bool {
 	}
 }
 
+// clients whose triggered alerts are currently polled
+var pollingClients sync.Map
+
+// StartFiringAlertsPoll polls the triggered alerts of the client in background unless they are already polled
+// returns true if a new polling has been started
+func StartFiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptnOptions keptn.KeptnOpts, envConfig utils.EnvConfig) bool {
+	if _, alreadyPolled := pollingClients.LoadOrStore(client, true); alreadyPolled {
+		return false
+	}
+
+	go func() {
+		defer pollingClients.Delete(client)
+		FiringAlertsPoll(client, ddKeptn, keptnOptions, envConfig)
+	}()
+	return true
+}
+
 // FiringAlertsPoll will handle all requests for '/health' and '/ready'
 func FiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptnOptions keptn.KeptnOpts, envConfig utils.EnvConfig) {
 
 	shkeptncontext := uuid.New().String()
 	logger := keptn.NewLogger(shkeptncontext, "", serviceName)
+	lastPrune := time.Now()
 
 	for {
 
+		// splunk is considered down, skip this cycle instead of piling up failing requests
+		if client.CircuitBreaker.IsOpen() {
+			logger.Info("Splunk is unavailable (circuit breaker open), skipping this polling cycle")
+			time.Sleep(pollingFrequency * time.Second)
+			continue
+		}
+
 		//listing fired alerts
 		logger.Info("Searching for triggered alerts ...")
 		triggeredAlerts, err := splunkalerts.GetTriggeredAlerts(client)
@@ -245,6 +271,17 @@ func FiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptn
 
 				for _, triggeredInstance := range triggeredInstances.Entry {
 					if triggeredInstance.Content.TriggerTime <= int(time.Now().Unix()) && triggeredInstance.Content.TriggerTime > int(time.Now().Unix())-pollingFrequency-2 {
+						// the instance is forwarded anyway if the store can not be read, rather than being lost
+						claimed, err := stateStore.Claim(triggeredInstance.Content.Sid, triggeredAlert.Name)
+						if err != nil {
+							logger.Errorf("Could not save the state of the triggered alert %s: %v", triggeredInstance.Content.Sid, err)
+							claimed = true
+						}
+						if !claimed {
+							logger.Debug("Triggered alert " + triggeredInstance.Content.Sid + " already forwarded")
+							continue
+						}
+
 						err = ProcessAndForwardAlertEvent(triggeredInstance, logger, client, ddKeptn, keptnOptions, envConfig)
 						switch err {
 						case nil:
@@ -252,6 +289,10 @@ func FiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptn
 
 						default:
 							logger.Errorf("Could not Process and Forward cloud event: %v", err)
+							err = stateStore.Release(triggeredInstance.Content.Sid)
+							if err != nil {
+								logger.Errorf("Could not release the state of the triggered alert %s: %v", triggeredInstance.Content.Sid, err)
+							}
 						}
 					}
 				}
@@ -259,6 +300,14 @@ func FiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptn
 			}
 
 		}
+		if time.Since(lastPrune) > pruneInterval {
+			err = stateStore.Prune(time.Now().Add(-stateRetention))
+			if err != nil {
+				logger.Errorf("Could not prune the state of the triggered alerts: %v", err)
+			}
+			lastPrune = time.Now()
+		}
+
 		// Condition only verified in case of a test
 		if ddKeptn != nil && isTestKeptn(ddKeptn.EventSender) {
 			return
@@ -84,6 +84,7 @@ func TestFiringAlertsPoll(t *testing.T) {
 	client := utils.ConnectToSplunk(*splunkCreds, true)
 
 	ddKeptn.UseLocalFileSystem = false
+	SetAlertStateStore(NewMemoryStateStore())
 	FiringAlertsPoll(client, ddKeptn, keptn.KeptnOpts{}, env)
 
 	gotEvents := len(ddKeptn.EventSender.(*fake.EventSender).SentEvents)
@@ -111,6 +112,55 @@ func TestFiringAlertsPoll(t *testing.T) {
 
 }
 
+func TestFiringAlertsPollSkipsForwardedAlerts(t *testing.T) {
+
+	splunkServer := buildMockAlertSplunkServer(t)
+	defer splunkServer.Close()
+
+	env := utils.EnvConfig{}
+	env.SplunkPort = strings.Split(splunkServer.URL, ":")[2]
+	env.SplunkHost = strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1]
+	env.SplunkApiToken = "apiToken"
+
+	ddKeptn, err := initializeObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	splunkCreds, err := utils.GetSplunkCredentials(env)
+	if err != nil {
+		t.Fatalf("failed to get Splunk Credentials: %v", err)
+	}
+	client := utils.ConnectToSplunk(*splunkCreds, true)
+
+	SetAlertStateStore(NewMemoryStateStore())
+	defer SetAlertStateStore(NewMemoryStateStore())
+
+	// two overlapping cycles see the same triggered instance
+	FiringAlertsPoll(client, ddKeptn, keptn.KeptnOpts{}, env)
+	FiringAlertsPoll(client, ddKeptn, keptn.KeptnOpts{}, env)
+
+	gotEvents := len(ddKeptn.EventSender.(*fake.EventSender).SentEvents)
+	if gotEvents != 1 {
+		t.Fatalf("Expected the triggered alert to be forwarded once, but got %v events", gotEvents)
+	}
+}
+
+func TestMemoryStateStorePrune(t *testing.T) {
+	store := NewMemoryStateStore()
+
+	_ = store.MarkProcessed("sid1", "alert")
+	processed, _ := store.IsProcessed("sid1")
+	if !processed {
+		t.Fatal("Expected sid1 to be processed")
+	}
+
+	_ = store.Prune(time.Now().Add(time.Minute))
+	processed, _ = store.IsProcessed("sid1")
+	if processed {
+		t.Fatal("Expected sid1 to be pruned")
+	}
+}
+
 /**
  * loads from files the default responses we want the fake splunk server to send
  */
@@ -0,0 +1,169 @@
+package alerts
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/kvstore"
+)
+
+const (
+	// forwarded instances are remembered during this period
+	stateRetention = 24 * time.Hour
+	pruneInterval  = time.Hour
+)
+
+// AlertStateStore remembers the triggered alert instances already forwarded to keptn
+//
+//	the pollers claim an instance before forwarding it, the claim being atomic in the store, so that an instance is
+//	forwarded once by overlapping polling cycles or by several replicas of the service sharing the store
+type AlertStateStore interface {
+	// whether the triggered instance with the given sid has already been forwarded
+	IsProcessed(sid string) (bool, error)
+	MarkProcessed(sid string, alertName string) error
+	// claim the triggered instance before forwarding it, false if it is already claimed
+	Claim(sid string, alertName string) (bool, error)
+	// forget a claimed instance which could not be forwarded, so that the next cycle forwards it
+	Release(sid string) error
+	// forget the instances forwarded before the given time
+	Prune(before time.Time) error
+}
+
+// state store used by the pollers, kept in memory unless SetAlertStateStore is called
+var stateStore AlertStateStore = NewMemoryStateStore()
+
+// Sets the state store used by the pollers
+func SetAlertStateStore(store AlertStateStore) {
+	stateStore = store
+}
+
+// MemoryStateStore keeps the state in the memory of the service, it is lost on restart and not shared between replicas
+type MemoryStateStore struct {
+	mu        sync.Mutex
+	processed map[string]time.Time
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{processed: map[string]time.Time{}}
+}
+
+func (m *MemoryStateStore) IsProcessed(sid string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.processed[sid]
+	return ok, nil
+}
+
+func (m *MemoryStateStore) MarkProcessed(sid string, alertName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.processed[sid] = time.Now()
+	return nil
+}
+
+func (m *MemoryStateStore) Claim(sid string, alertName string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.processed[sid]; ok {
+		return false, nil
+	}
+	m.processed[sid] = time.Now()
+	return true, nil
+}
+
+func (m *MemoryStateStore) Release(sid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.processed, sid)
+	return nil
+}
+
+func (m *MemoryStateStore) Prune(before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for sid, processedAt := range m.processed {
+		if processedAt.Before(before) {
+			delete(m.processed, sid)
+		}
+	}
+	return nil
+}
+
+// KVStateStore keeps the state in a collection of the splunk KV Store, keyed by the sid of the triggered instances
+type KVStateStore struct {
+	client     *splunk.SplunkClient
+	namespace  kvstore.Namespace
+	collection string
+}
+
+// record of a forwarded triggered instance
+type alertStateRecord struct {
+	Sid       string `json:"_key"`
+	AlertName string `json:"alert"`
+	// epoch time in seconds
+	ProcessedAt int64 `json:"processedAt"`
+}
+
+// create a store in the given collection, which is created if it does not exist
+func NewKVStateStore(client *splunk.SplunkClient, namespace kvstore.Namespace, collection string) (*KVStateStore, error) {
+
+	err := kvstore.EnsureCollection(client, namespace, collection, map[string]string{"alert": "string", "processedAt": "number"})
+	if err != nil {
+		return nil, fmt.Errorf("could not create the kv store collection %s: %w", collection, err)
+	}
+
+	return &KVStateStore{client: client, namespace: namespace, collection: collection}, nil
+}
+
+func (k *KVStateStore) IsProcessed(sid string) (bool, error) {
+
+	var record alertStateRecord
+	err := kvstore.GetRecord(k.client, k.namespace, k.collection, sid, &record)
+	if splunk.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (k *KVStateStore) MarkProcessed(sid string, alertName string) error {
+
+	// batch_save inserts the record or replaces it if the sid is already known
+	_, err := kvstore.BatchSave(k.client, k.namespace, k.collection, []alertStateRecord{{Sid: sid, AlertName: alertName, ProcessedAt: time.Now().Unix()}})
+	return err
+}
+
+func (k *KVStateStore) Claim(sid string, alertName string) (bool, error) {
+
+	// the insertion fails with a conflict if another cycle or replica already inserted the sid
+	_, err := kvstore.InsertRecord(k.client, k.namespace, k.collection, alertStateRecord{Sid: sid, AlertName: alertName, ProcessedAt: time.Now().Unix()})
+	if splunk.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (k *KVStateStore) Release(sid string) error {
+
+	err := kvstore.DeleteRecord(k.client, k.namespace, k.collection, sid)
+	if splunk.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (k *KVStateStore) Prune(before time.Time) error {
+	return kvstore.DeleteRecords(k.client, k.namespace, k.collection, map[string]interface{}{"processedAt": map[string]int64{"$lt": before.Unix()}})
+}
@@ -0,0 +1,168 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/kvstore"
+	splunktest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+// Builds a fake kv store holding the collection keptn_alerts, the collection already exists
+func buildMockKVStore(t *testing.T) *httptest.Server {
+	const dataPath = "/servicesNS/nobody/keptn/storage/collections/data/keptn_alerts"
+	var mu sync.Mutex
+	records := map[string]alertStateRecord{}
+
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+
+		switch {
+		case r.URL.Path == "/servicesNS/nobody/keptn/storage/collections/config":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"An object with name=keptn_alerts already exists"}]}`))
+		case r.URL.Path == dataPath+"/batch_save":
+			var batch []alertStateRecord
+			_ = json.Unmarshal(body, &batch)
+			keys := []string{}
+			for _, record := range batch {
+				records[record.Sid] = record
+				keys = append(keys, record.Sid)
+			}
+			_ = json.NewEncoder(w).Encode(keys)
+		case r.URL.Path == dataPath && r.Method == http.MethodPost:
+			var record alertStateRecord
+			_ = json.Unmarshal(body, &record)
+			if _, ok := records[record.Sid]; ok {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"A document with the same key already exists"}]}`))
+				return
+			}
+			records[record.Sid] = record
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"_key":"` + record.Sid + `"}`))
+		case strings.HasPrefix(r.URL.Path, dataPath+"/") && r.Method == http.MethodDelete:
+			sid := strings.TrimPrefix(r.URL.Path, dataPath+"/")
+			if _, ok := records[sid]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"Could not find object."}]}`))
+				return
+			}
+			delete(records, sid)
+		case r.URL.Path == dataPath && r.Method == http.MethodDelete:
+			var filter struct {
+				ProcessedAt struct {
+					Lt int64 `json:"$lt"`
+				} `json:"processedAt"`
+			}
+			_ = json.Unmarshal([]byte(r.URL.Query().Get("query")), &filter)
+			for sid, record := range records {
+				if record.ProcessedAt < filter.ProcessedAt.Lt {
+					delete(records, sid)
+				}
+			}
+		case strings.HasPrefix(r.URL.Path, dataPath+"/") && r.Method == http.MethodGet:
+			record, ok := records[strings.TrimPrefix(r.URL.Path, dataPath+"/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"Could not find object."}]}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(record)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestKVStateStore(t *testing.T) {
+	server := buildMockKVStore(t)
+	defer server.Close()
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, splunktest.GetTestHostname(server), splunktest.GetTestPort(server), splunktest.GetTestToken(), true)
+
+	store, err := NewKVStateStore(client, kvstore.Namespace{Owner: "nobody", App: "keptn"}, "keptn_alerts")
+	if err != nil {
+		t.Fatalf("Expected an existing collection to be reused but got %v", err)
+	}
+
+	processed, err := store.IsProcessed("sid1")
+	if err != nil || processed {
+		t.Fatalf("Expected sid1 not to be processed yet : %v", err)
+	}
+
+	err = store.MarkProcessed("sid1", "fulltour2,production,helloservice,number_of_logs,keptn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	processed, err = store.IsProcessed("sid1")
+	if err != nil || !processed {
+		t.Fatalf("Expected sid1 to be processed : %v", err)
+	}
+
+	err = store.Prune(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	processed, err = store.IsProcessed("sid1")
+	if err != nil || processed {
+		t.Fatalf("Expected sid1 to be pruned : %v", err)
+	}
+}
+
+// Tests that a triggered instance is claimed by a single replica sharing the kv store until the claim is released
+func TestKVStateStoreClaim(t *testing.T) {
+	server := buildMockKVStore(t)
+	defer server.Close()
+
+	var stores []*KVStateStore
+	for i := 0; i < 2; i++ {
+		client := splunk.NewClientAuthenticatedByToken(&http.Client{}, splunktest.GetTestHostname(server), splunktest.GetTestPort(server), splunktest.GetTestToken(), true)
+		store, err := NewKVStateStore(client, kvstore.Namespace{Owner: "nobody", App: "keptn"}, "keptn_alerts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, store)
+	}
+
+	var wg sync.WaitGroup
+	claims := make([]bool, len(stores))
+	for i, store := range stores {
+		wg.Add(1)
+		go func(i int, store *KVStateStore) {
+			defer wg.Done()
+			var err error
+			claims[i], err = store.Claim("sid1", "fulltour2,production,helloservice,number_of_logs,keptn")
+			if err != nil {
+				t.Errorf("Got an error : %s", err)
+			}
+		}(i, store)
+	}
+	wg.Wait()
+	if claims[0] == claims[1] {
+		t.Fatalf("Expected a single replica to claim sid1 but got %v", claims)
+	}
+
+	// a released instance is claimed again by the next cycle
+	err := stores[0].Release("sid1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := stores[1].Claim("sid1", "fulltour2,production,helloservice,number_of_logs,keptn")
+	if err != nil || !claimed {
+		t.Fatalf("Expected the released sid1 to be claimed again : %v", err)
+	}
+	err = stores[0].Release("sid2")
+	if err != nil {
+		t.Fatalf("Expected the release of an unknown instance to be ignored but got %v", err)
+	}
+}
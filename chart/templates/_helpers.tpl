@@ -72,3 +72,18 @@ Secret
 {{include "splunk-service.fullname" .}}
 {{- end }}
 {{- end }}
+
+
+{{/*
+Events the service subscribes to, including the ones sent to the HTTP Event Collector
+*/}}
+{{- define "splunk-service.pubsubTopic" -}}
+{{- $topics := splitList "," .Values.subscription.pubsubTopic }}
+{{- if .Values.splunkservice.hec.url }}
+{{- $topics = concat $topics (splitList "," .Values.splunkservice.hec.subscription) }}
+{{- if .Values.splunkservice.hec.annotations }}
+{{- $topics = concat $topics (list "sh.keptn.event.deployment.finished" "sh.keptn.event.release.finished") }}
+{{- end }}
+{{- end }}
+{{- $topics | compact | uniq | join "," }}
+{{- end }}
\ No newline at end of file
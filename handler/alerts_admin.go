@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	splunkalerts "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/alerts"
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+
+	"github.com/keptn/go-utils/pkg/api/models"
+)
+
+// KeptnAlert is an alert created by the service for a criteria of an objective, read from its name
+type KeptnAlert struct {
+	Name     string
+	Project  string
+	Stage    string
+	Service  string
+	SLI      string
+	Criteria string
+}
+
+// FiredAlert is a triggering of a keptn alert kept by splunk
+type FiredAlert struct {
+	KeptnAlert
+	Sid         string
+	TriggerTime time.Time
+}
+
+// ParseAlertName reads the project, stage, service, SLI and criteria of an alert named by buildAlertName
+//
+//	the alerts which were not created by the service are not parsed
+func ParseAlertName(name string) (KeptnAlert, bool) {
+	parts := strings.Split(name, ",")
+	if len(parts) != 6 || parts[5] != KeptnSuffix {
+		return KeptnAlert{}, false
+	}
+	return KeptnAlert{
+		Name:     name,
+		Project:  parts[0],
+		Stage:    parts[1],
+		Service:  parts[2],
+		SLI:      parts[3],
+		Criteria: parts[4],
+	}, true
+}
+
+// ListKeptnAlerts returns the alerts created by the service, sorted by name
+func ListKeptnAlerts(client *splunk.SplunkClient) ([]KeptnAlert, error) {
+
+	alertsList, err := splunkalerts.ListAlertsNames(client)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing the alerts : %w", err)
+	}
+
+	keptnAlerts := []KeptnAlert{}
+	for _, alert := range alertsList.Item {
+		if keptnAlert, ok := ParseAlertName(alert.Name); ok {
+			keptnAlerts = append(keptnAlerts, keptnAlert)
+		}
+	}
+	sort.Slice(keptnAlerts, func(i, j int) bool { return keptnAlerts[i].Name < keptnAlerts[j].Name })
+	return keptnAlerts, nil
+}
+
+// ListFiredAlerts returns the triggerings of the keptn alerts kept by splunk, the latest first
+func ListFiredAlerts(client *splunk.SplunkClient) ([]FiredAlert, error) {
+
+	triggeredAlerts, err := splunkalerts.GetTriggeredAlerts(client)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing the triggered alerts : %w", err)
+	}
+
+	firedAlerts := []FiredAlert{}
+	for _, triggeredAlert := range triggeredAlerts.Entry {
+		keptnAlert, ok := ParseAlertName(triggeredAlert.Name)
+		if !ok {
+			continue
+		}
+		triggeredInstances, err := splunkalerts.GetInstancesOfTriggeredAlert(client, triggeredAlert.Links.List)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing the triggerings of %s : %w", triggeredAlert.Name, err)
+		}
+		for _, instance := range triggeredInstances.Entry {
+			firedAlerts = append(firedAlerts, FiredAlert{
+				KeptnAlert:  keptnAlert,
+				Sid:         instance.Content.Sid,
+				TriggerTime: time.Unix(int64(instance.Content.TriggerTime), 0).UTC(),
+			})
+		}
+	}
+	sort.SliceStable(firedAlerts, func(i, j int) bool { return firedAlerts[i].TriggerTime.After(firedAlerts[j].TriggerTime) })
+	return firedAlerts, nil
+}
+
+// KeptnServices returns the services of each keptn project, whatever their stage
+func KeptnServices(projects []*models.Project) map[string]map[string]bool {
+	services := map[string]map[string]bool{}
+	for _, project := range projects {
+		services[project.ProjectName] = map[string]bool{}
+		for _, stage := range project.Stages {
+			for _, service := range stage.Services {
+				services[project.ProjectName][service.ServiceName] = true
+			}
+		}
+	}
+	return services
+}
+
+// OrphanedAlerts returns the alerts whose project or service no longer exists in keptn
+func OrphanedAlerts(keptnAlerts []KeptnAlert, services map[string]map[string]bool) []KeptnAlert {
+	orphaned := []KeptnAlert{}
+	for _, alert := range keptnAlerts {
+		if !services[alert.Project][alert.Service] {
+			orphaned = append(orphaned, alert)
+		}
+	}
+	return orphaned
+}
+
+// RemoveAlerts removes the alerts from splunk, the ones already removed are ignored
+func RemoveAlerts(client *splunk.SplunkClient, keptnAlerts []KeptnAlert) error {
+	for _, alert := range keptnAlerts {
+		err := splunkalerts.RemoveAlert(client, alert.Name)
+		if err != nil && !splunk.IsNotFound(err) {
+			return fmt.Errorf("error while removing the alert %s : %w", alert.Name, err)
+		}
+	}
+	return nil
+}
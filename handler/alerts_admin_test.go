@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	splunktest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+
+	"github.com/keptn/go-utils/pkg/api/models"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+const firedAlertInstancesFilePath = "../test/data/unitTests/firedAlertInstances.json"
+
+// Tests that the names built for the alerts are parsed back and that the other alerts are ignored
+func TestParseAlertName(t *testing.T) {
+	eventData := keptnv2.ConfigureMonitoringTriggeredEventData{
+		EventData: keptnv2.EventData{Project: project, Service: service},
+	}
+	name := buildAlertName(eventData, stage, problemTitle, "<=9000")
+
+	alert, ok := ParseAlertName(name)
+	expected := KeptnAlert{Name: name, Project: project, Stage: stage, Service: service, SLI: problemTitle, Criteria: "<=9000"}
+	if !ok || alert != expected {
+		t.Fatalf("Expected %+v but got %+v", expected, alert)
+	}
+
+	for _, name := range []string{"Errors in the last hour", "a,b,c,d,>1,other", "a,b,keptn"} {
+		if _, ok := ParseAlertName(name); ok {
+			t.Errorf("Expected %s not to be a keptn alert", name)
+		}
+	}
+}
+
+// Tests the listing of the keptn alerts and of their triggerings
+func TestListKeptnAlerts(t *testing.T) {
+	firedAlerts, err := initializeResponses(alertNamesFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firedAlertInstances, err := initializeResponses(firedAlertInstancesFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	splunkResponses := []map[string]interface{}{{
+		splunktest.GetAlertsNames:        `{"entry":[{"name":"fulltour2,production,helloservice,number_of_logs,<=9000,keptn"},{"name":"Errors in the last hour"}]}`,
+		splunktest.GetTriggeredAlerts:    firedAlerts,
+		splunktest.GetTriggeredInstances: firedAlertInstances,
+	}}
+	splunkServer := splunktest.MultitpleMockRequest(splunkResponses, true)
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		true,
+	)
+
+	keptnAlerts, err := ListKeptnAlerts(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keptnAlerts) != 1 || keptnAlerts[0].SLI != problemTitle || keptnAlerts[0].Criteria != "<=9000" {
+		t.Fatalf("Expected the keptn alert only but got %+v", keptnAlerts)
+	}
+
+	fired, err := ListFiredAlerts(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fired) == 0 || fired[0].Project != project || fired[0].TriggerTime.Unix() != 1689080402 {
+		t.Fatalf("Expected the triggerings of the keptn alert but got %+v", fired)
+	}
+
+	err = RemoveAlerts(client, keptnAlerts)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests that the alerts of the projects and services removed from keptn are orphaned
+func TestOrphanedAlerts(t *testing.T) {
+	projects := []*models.Project{{
+		ProjectName: "podtatohead",
+		Stages: []*models.Stage{
+			{StageName: "hardening", Services: []*models.Service{{ServiceName: "helloservice"}}},
+			{StageName: "production", Services: []*models.Service{{ServiceName: "helloservice"}, {ServiceName: "carts"}}},
+		},
+	}}
+	keptnAlerts := []KeptnAlert{
+		{Name: "1", Project: "podtatohead", Stage: "production", Service: "carts"},
+		{Name: "2", Project: "podtatohead", Stage: "hardening", Service: "helloservice"},
+		{Name: "3", Project: "podtatohead", Stage: "production", Service: "orders"},
+		{Name: "4", Project: "fulltour", Stage: "production", Service: "helloservice"},
+	}
+
+	orphaned := OrphanedAlerts(keptnAlerts, KeptnServices(projects))
+	if len(orphaned) != 2 || orphaned[0].Name != "3" || orphaned[1].Name != "4" {
+		t.Fatalf("Expected the alerts of the removed service and project but got %+v", orphaned)
+	}
+}
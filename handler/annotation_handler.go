@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2" // make sure to use v2 cloudevents here
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	logger "github.com/sirupsen/logrus"
+)
+
+// colors of the annotations depending on the result of the task
+var annotationColors = map[keptnv2.ResultType]string{
+	keptnv2.ResultPass:    "#4CAF50",
+	keptnv2.ResultWarning: "#FF9800",
+	keptnv2.ResultFailed:  "#F44336",
+}
+
+// data of the deployment.finished and release.finished events used in the annotations
+type annotatedEventData struct {
+	keptnv2.EventData
+	ConfigurationChange struct {
+		Values map[string]interface{} `json:"values"`
+	} `json:"configurationChange"`
+	Deployment keptnv2.DeploymentFinishedData `json:"deployment"`
+}
+
+// HandleDeploymentAnnotationEvent writes an annotation event to splunk for deployment.finished and release.finished events
+func HandleDeploymentAnnotationEvent(batcher *hec.Batcher, incomingEvent cloudevents.Event, envConfig utils.EnvConfig) error {
+
+	data := &annotatedEventData{}
+	err := incomingEvent.DataAs(data)
+	if err != nil {
+		return fmt.Errorf("could not parse the data of the event %s: %w", incomingEvent.ID(), err)
+	}
+
+	task := keptnv2.DeploymentTaskName
+	if incomingEvent.Type() == keptnv2.GetFinishedEventType(keptnv2.ReleaseTaskName) {
+		task = keptnv2.ReleaseTaskName
+	}
+
+	image, _ := data.ConfigurationChange.Values["image"].(string)
+	version := annotationVersion(data.Labels, image)
+
+	var shkeptncontext string
+	_ = incomingEvent.ExtensionAs("shkeptncontext", &shkeptncontext)
+
+	label := fmt.Sprintf("%s of %s", strings.ToUpper(task[:1])+task[1:], data.Service)
+	if version != "" {
+		label += " " + version
+	}
+	label += fmt.Sprintf(" in %s", data.Stage)
+	if data.Result != "" {
+		label += fmt.Sprintf(" (%s)", data.Result)
+	}
+
+	annotationTime := incomingEvent.Time()
+	if annotationTime.IsZero() {
+		annotationTime = time.Now()
+	}
+
+	event := hec.NewAnnotationEvent(hec.Annotation{
+		Time:     annotationTime,
+		Label:    label,
+		Category: task,
+		Color:    annotationColors[data.Result],
+		Fields: map[string]string{
+			"project":             data.Project,
+			"stage":               data.Stage,
+			"service":             data.Service,
+			"version":             version,
+			"image":               image,
+			"result":              string(data.Result),
+			"status":              string(data.Status),
+			"keptn_context":       shkeptncontext,
+			"triggered_id":        triggeredID(incomingEvent),
+			"deployment_strategy": data.Deployment.DeploymentStrategy,
+			"deployment_urls":     strings.Join(append(data.Deployment.DeploymentURIsPublic, data.Deployment.DeploymentURIsLocal...), ","),
+		},
+	})
+	event.Source = envConfig.SplunkHECSource
+	event.SourceType = envConfig.SplunkHECAnnotationsSourceType
+	event.Index = envConfig.SplunkHECAnnotationsIndex
+	if event.Index == "" {
+		event.Index = envConfig.SplunkHECIndex
+	}
+
+	logger.Infof("Annotating splunk with: %s", label)
+	utils.ForwardHECEvent(batcher, event)
+
+	return nil
+}
+
+// return the version of the service from the labels of the event or the tag of its image
+func annotationVersion(labels map[string]string, image string) string {
+	for _, label := range versionLabels {
+		if labels[label] != "" {
+			return labels[label]
+		}
+	}
+
+	tagIndex := strings.LastIndex(image, ":")
+	if tagIndex > strings.LastIndex(image, "/") {
+		return image[tagIndex+1:]
+	}
+	return ""
+}
+
+// return the id of the triggered event the event answers to
+func triggeredID(incomingEvent cloudevents.Event) string {
+	var id string
+	_ = incomingEvent.ExtensionAs("triggeredid", &id)
+	return id
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+)
+
+const deploymentFinishedEventFile = "../test/events/deployment.finished.json"
+
+// Tests that the HandleDeploymentAnnotationEvent function writes an annotation of the deployment
+func TestHandleDeploymentAnnotationEvent(t *testing.T) {
+	var received []hec.Event
+	collector, batcher, envConfig := buildMockCollector(t, &received)
+	defer collector.Close()
+	envConfig.SplunkHECAnnotationsSourceType = "keptn:annotation"
+
+	err := HandleDeploymentAnnotationEvent(batcher, readCloudEvent(t, deploymentFinishedEventFile), envConfig)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	err = batcher.Flush()
+	if err != nil || len(received) != 1 {
+		t.Fatalf("Expected one annotation but got %d : %v", len(received), err)
+	}
+
+	annotation := received[0]
+	if annotation.Index != "keptn" || annotation.SourceType != "keptn:annotation" || annotation.Time != 1610722992.006 {
+		t.Fatalf("Unexpected annotation metadata %+v", annotation)
+	}
+	content, ok := annotation.Event.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Unexpected annotation %v", annotation.Event)
+	}
+	expected := map[string]string{
+		"annotation_label":    "Deployment of helloservice v0.1.1 in hardening (pass)",
+		"annotation_category": "deployment",
+		"annotation_color":    "#4CAF50",
+		"version":             "v0.1.1",
+		"image":               "ghcr.io/podtato-head/podtatoserver:v0.1.1",
+		"keptn_context":       "da7aec34-78c4-4182-a2c8-51eb88f5871d",
+		"result":              "pass",
+		"deployment_strategy": "blue_green_service",
+	}
+	for name, value := range expected {
+		if content[name] != value {
+			t.Errorf("Expected %s for %s but got %v", value, name, content[name])
+		}
+	}
+}
+
+// Tests the annotationVersion function
+func TestAnnotationVersion(t *testing.T) {
+	if version := annotationVersion(map[string]string{"buildId": "42"}, "podtatoserver:v0.1.1"); version != "42" {
+		t.Errorf("Expected the version of the label but got %s", version)
+	}
+	if version := annotationVersion(nil, "localhost:5000/podtatoserver"); version != "" {
+		t.Errorf("Expected no version for an image without tag but got %s", version)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/ECL2022PAI01/splunk-service/alerts"
 	splunkalerts "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/alerts"
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	splunkjobs "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/jobs"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
 	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2" // make sure to use v2 cloudevents here
@@ -23,7 +25,7 @@ import (
 var createAlert = splunkalerts.CreateAlert
 
 // Handles configure monitoring event
-func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevents.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig, client *splunk.SplunkClient, pollingSystemHasBeenStarted bool) error {
+func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevents.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig, client *splunk.SplunkClient) error {
 
 	if isNotForSplunk(data.ConfigureMonitoring.Type) {
 		logger.Infof("Event is not for splunk but for %s", data.ConfigureMonitoring.Type)
@@ -49,23 +51,21 @@ func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEve
 	}
 
 	//Creating the alerts
-	setPollingSystem, err := CreateSplunkAlertsForEachStage(client, ddKeptn, *data, envConfig)
+	alertClients, err := CreateSplunkAlertsForEachStage(client, ddKeptn, *data, envConfig)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
 	}
 
-	switch {
-	case !pollingSystemHasBeenStarted && setPollingSystem:
-		go func() {
-			// Starts polling for triggered alerts if configure monitoring is successful
-			alerts.FiringAlertsPoll(client, ddKeptn, keptn.KeptnOpts{}, envConfig)
-		}()
-	case pollingSystemHasBeenStarted:
-		logger.Info("Polling system has already been started")
-	default:
+	if len(alertClients) == 0 {
 		logger.Info("No alerts configured, no need to start the polling system")
 	}
+	for _, alertClient := range alertClients {
+		// Starts polling for triggered alerts if configure monitoring is successful
+		if !alerts.StartFiringAlertsPoll(alertClient, ddKeptn, keptn.KeptnOpts{}, envConfig) {
+			logger.Info("Polling system has already been started")
+		}
+	}
 
 	//Making the configure monitoring finished event
 	configureMonitoringFinishedEventData := &keptnv2.ConfigureMonitoringFinishedEventData{
@@ -93,7 +93,53 @@ func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEve
 }
 
 // Creates alerts for each stage defined in the shipyard file after removing potential ancient alerts of the service
-func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig) (bool, error) {
+// Returns the clients of the splunk instances on which alerts have been created
+func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig) ([]*splunk.SplunkClient, error) {
+
+	//Getting the shipyard configuration
+	scope := api.NewResourceScope()
+	scope.Project(eventData.Project)
+	scope.Resource("shipyard.yaml")
+
+	shipyard, err := k.GetShipyard()
+	if err != nil {
+		return nil, err
+	}
+
+	// clients of the splunk instances already cleaned and the ones on which alerts have been created
+	cleanedClients := map[*splunk.SplunkClient]bool{}
+	alertClients := []*splunk.SplunkClient{}
+
+	//Creating the alerts for each stage of the shipyard file
+	for _, stage := range shipyard.Spec.Stages {
+		stageClient, err := selectSplunkClient(k, eventData.Project, stage.Name, eventData.Service, eventData.Labels, client)
+		if err != nil {
+			return nil, fmt.Errorf("error selecting the splunk connection of stage %s: %w", stage.Name, err)
+		}
+
+		if !cleanedClients[stageClient] {
+			err = removeServiceAlerts(stageClient, eventData)
+			if err != nil {
+				return nil, err
+			}
+			cleanedClients[stageClient] = true
+		}
+
+		logger.Infof("Creating alerts for stage : %v", stage)
+		setPollingSystem, err := CreateSplunkAlerts(stageClient, k, eventData, stage, envConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring splunk alerts: %w", err)
+		}
+		if setPollingSystem && !containsClient(alertClients, stageClient) {
+			alertClients = append(alertClients, stageClient)
+		}
+	}
+
+	return alertClients, nil
+}
+
+// Removes the alerts previously created for the project and the service
+func removeServiceAlerts(client *splunk.SplunkClient, eventData keptnv2.ConfigureMonitoringTriggeredEventData) error {
 
 	logger.Infof("Removing previous alerts set for the service %v in project %v", eventData.Service, eventData.Project)
 
@@ -101,7 +147,7 @@ func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Kept
 	alertsList, err := splunkalerts.ListAlertsNames(client)
 	if err != nil {
 		logger.Errorf("Error calling ListAlertsNames(): %v : %v", alertsList, err)
-		return false, fmt.Errorf("error calling ListAlertsNames(): %v : %w", alertsList, err)
+		return fmt.Errorf("error calling ListAlertsNames(): %v : %w", alertsList, err)
 	}
 
 	//removing all preexisting alerts concerning the project and the service
@@ -109,38 +155,28 @@ func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Kept
 		if strings.HasSuffix(alert.Name, KeptnSuffix) && strings.Contains(alert.Name, eventData.Project) && strings.Contains(alert.Name, eventData.Service) {
 			logger.Infof("Removing alert %v", alert.Name)
 			err := splunkalerts.RemoveAlert(client, alert.Name)
+			if splunk.IsNotFound(err) {
+				logger.Infof("Alert %v has already been removed", alert.Name)
+				continue
+			}
 			if err != nil {
 				logger.Errorf("Error calling RemoveAlert(): %v : %v", alertsList, err)
-				return false, fmt.Errorf("error calling RemoveAlert(): %v : %w", alertsList, err)
+				return fmt.Errorf("error calling RemoveAlert(): %v : %w", alertsList, err)
 			}
 		}
 	}
 
-	// if no alerts are configured, no need to start the polling system
-	var setPollingSystem bool
-	//Getting the shipyard configuration
-	scope := api.NewResourceScope()
-	scope.Project(eventData.Project)
-	scope.Resource("shipyard.yaml")
-
-	shipyard, err := k.GetShipyard()
-	if err != nil {
-		return false, err
-	}
+	return nil
+}
 
-	//Creating the alerts for each stage of the shipyard file
-	for _, stage := range shipyard.Spec.Stages {
-		logger.Infof("Creating alerts for stage : %v", stage)
-		setPollingSystemTmp, err := CreateSplunkAlerts(client, k, eventData, stage, envConfig)
-		if err != nil {
-			return false, fmt.Errorf("error configuring splunk alerts: %w", err)
-		}
-		if setPollingSystemTmp {
-			setPollingSystem = true
+// check if the client is in the list
+func containsClient(clients []*splunk.SplunkClient, client *splunk.SplunkClient) bool {
+	for _, c := range clients {
+		if c == client {
+			return true
 		}
 	}
-
-	return setPollingSystem, nil
+	return false
 }
 
 // Creates the splunk alerts of a particular stage if slo.yaml and remediation.yaml files are defined
@@ -194,7 +230,17 @@ func CreateSplunkAlerts(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData
 		logger.Info("SLO: " + objective.DisplayName + ", " + objective.SLI)
 
 		//getting the splunk search query for the objective
-		query := projectCustomQueries[objective.SLI]
+		definition := projectCustomQueries[objective.SLI]
+
+		// the alert of a saved search runs its query
+		if definition.SavedSearch != "" {
+			savedSearch, err := splunkjobs.GetSavedSearch(client, definition.SavedSearch)
+			if err != nil {
+				return false, fmt.Errorf("failed to get the saved search of SLI %s: %w", objective.SLI, err)
+			}
+			definition.Query = savedSearch.Search
+		}
+		query := definition.Query
 
 		if err != nil || query == "" {
 			logger.Error("No query defined for SLI " + objective.SLI + " in project " + eventData.Project)
@@ -202,8 +248,13 @@ func CreateSplunkAlerts(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData
 		}
 		logger.Info("query= " + query)
 
+		parsed, err := preflightQuery(client, query)
+		if err != nil {
+			return false, fmt.Errorf("invalid query for SLI %s: %w", objective.SLI, err)
+		}
+
 		//getting the name of the result field of the splunk sli search
-		resultField, err := getResultFieldName(query)
+		resultField, err := getResultFieldName(definition, parsed)
 		if err != nil {
 			log.Println("Failed to get the result field name in order to create the alert condition for " + eventData.Project)
 			log.Println(err.Error())
@@ -211,76 +262,85 @@ func CreateSplunkAlerts(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData
 		}
 
 		//For each criteria of each pass criteria group of an objective (corresponding to an sli)
-		if objective.Pass != nil {
-			for _, criteriaGroup := range objective.Pass {
-				for _, criteria := range criteriaGroup.Criteria {
-
-					//building the splunk alert condition
-					//TO SUPPORT RELATIVE CRITERIA I'LL HAVE TO MODIFY THAT PART
-					if strings.Contains(criteria, "+") || strings.Contains(criteria, "-") || strings.Contains(
-						criteria, "%",
-					) || (!strings.Contains(criteria, "<") && !strings.Contains(criteria, ">")) {
-						continue
-					}
-
-					switch {
-					case strings.Contains(criteria, "<="):
-						criteria = strings.Replace(criteria, "<=", ">", -1)
-					case strings.Contains(criteria, "<"):
-						criteria = strings.Replace(criteria, "<", ">=", -1)
-					case strings.Contains(criteria, ">="):
-						criteria = strings.Replace(criteria, ">=", "<", -1)
-					case strings.Contains(criteria, ">"):
-						criteria = strings.Replace(criteria, ">", "<=", -1)
-					case strings.Contains(criteria, "="):
-						criteria = strings.Replace(criteria, "=", "!=", -1)
-					default:
-						criteria = strings.Replace(criteria, "!=", "=", -1)
-					}
-
-					//Sanitize criteria : remove whitespaces
-					criteria = strings.Replace(criteria, " ", "", -1)
-
-					//Setting some alert parameters
-					alertCondition := buildAlertCondition(resultField, criteria)
-					alertName := buildAlertName(eventData, stage.Name, objective.SLI, criteria)
-					cronSchedule := "*/1 * * * *"
-					alertSuppress := "1"
-
-					//Creates the alert datastructure
-					params := splunkalerts.AlertParams{
-						Name:                alertName,
-						CronSchedule:        cronSchedule,
-						SearchQuery:         query,
-						EarliestTime:        envConfig.DispatchEarliestTime,
-						LatestTime:          envConfig.DispatchLatestTime,
-						AlertCondition:      alertCondition,
-						AlertSuppress:       alertSuppress,
-						AlertSuppressPeriod: envConfig.AlertSuppressPeriod,
-						Actions:             envConfig.Actions,
-						WebhookUrl:          envConfig.WebhookUrl,
-					}
-					params.EarliestTime, params.LatestTime, params.SearchQuery = utils.RetrieveQueryTimeRange(params.EarliestTime, params.LatestTime, params.SearchQuery)
-
-					spAlert := splunkalerts.AlertRequest{
-						Params:  params,
-						Headers: map[string]string{},
-					}
-
-					//Creates the alert in splunk
-					err = createAlert(client, &spAlert)
-					if err != nil {
-						logger.Errorf("Error calling CreateAlert(): %v : %v", spAlert.Params.SearchQuery, err)
-						return false, fmt.Errorf("error calling CreateAlert(): %v : %w", spAlert.Params.SearchQuery, err)
-					}
-
-				}
+		for _, params := range buildObjectiveAlerts(eventData, stage.Name, objective, query, resultField, envConfig) {
+			spAlert := splunkalerts.AlertRequest{
+				Params:  params,
+				Headers: map[string]string{},
+			}
+
+			//Creates the alert in splunk
+			err = createAlert(client, &spAlert)
+			if err != nil {
+				logger.Errorf("Error calling CreateAlert(): %v : %v", spAlert.Params.SearchQuery, err)
+				return false, fmt.Errorf("error calling CreateAlert(): %v : %w", spAlert.Params.SearchQuery, err)
 			}
 		}
 	}
 	return true, nil
 }
 
+// Builds the parameters of the alerts of an objective, one for each of its absolute pass criteria
+//
+//	the alert is triggered when the result field of the query breaks the criteria
+func buildObjectiveAlerts(eventData keptnv2.ConfigureMonitoringTriggeredEventData, stage string, objective *keptnevents.SLO, query string, resultField string, envConfig utils.EnvConfig) []splunkalerts.AlertParams {
+	var alertParams []splunkalerts.AlertParams
+
+	for _, criteriaGroup := range objective.Pass {
+		for _, criteria := range criteriaGroup.Criteria {
+
+			//building the splunk alert condition
+			//TO SUPPORT RELATIVE CRITERIA I'LL HAVE TO MODIFY THAT PART
+			if strings.Contains(criteria, "+") || strings.Contains(criteria, "-") || strings.Contains(
+				criteria, "%",
+			) || (!strings.Contains(criteria, "<") && !strings.Contains(criteria, ">")) {
+				continue
+			}
+
+			switch {
+			case strings.Contains(criteria, "<="):
+				criteria = strings.Replace(criteria, "<=", ">", -1)
+			case strings.Contains(criteria, "<"):
+				criteria = strings.Replace(criteria, "<", ">=", -1)
+			case strings.Contains(criteria, ">="):
+				criteria = strings.Replace(criteria, ">=", "<", -1)
+			case strings.Contains(criteria, ">"):
+				criteria = strings.Replace(criteria, ">", "<=", -1)
+			case strings.Contains(criteria, "="):
+				criteria = strings.Replace(criteria, "=", "!=", -1)
+			default:
+				criteria = strings.Replace(criteria, "!=", "=", -1)
+			}
+
+			//Sanitize criteria : remove whitespaces
+			criteria = strings.Replace(criteria, " ", "", -1)
+
+			//Setting some alert parameters
+			alertCondition := buildAlertCondition(resultField, criteria)
+			alertName := buildAlertName(eventData, stage, objective.SLI, criteria)
+			cronSchedule := "*/1 * * * *"
+			alertSuppress := "1"
+
+			//Creates the alert datastructure
+			params := splunkalerts.AlertParams{
+				Name:                alertName,
+				CronSchedule:        cronSchedule,
+				SearchQuery:         query,
+				EarliestTime:        envConfig.DispatchEarliestTime,
+				LatestTime:          envConfig.DispatchLatestTime,
+				AlertCondition:      alertCondition,
+				AlertSuppress:       alertSuppress,
+				AlertSuppressPeriod: envConfig.AlertSuppressPeriod,
+				Actions:             envConfig.Actions,
+				WebhookUrl:          envConfig.WebhookUrl,
+			}
+			params.EarliestTime, params.LatestTime, params.SearchQuery = utils.RetrieveQueryTimeRange(params.EarliestTime, params.LatestTime, params.SearchQuery)
+
+			alertParams = append(alertParams, params)
+		}
+	}
+	return alertParams
+}
+
 // Retrieves the SLOs from the slo.yaml file
 func retrieveSLOs(resourceHandler *api.ResourceHandler, eventData keptnv2.ConfigureMonitoringTriggeredEventData, stage string) (*keptnevents.ServiceLevelObjectives, error) {
 	resourceScope := api.NewResourceScope()
@@ -305,10 +365,10 @@ func retrieveSLOs(resourceHandler *api.ResourceHandler, eventData keptnv2.Config
 }
 
 // Returns the splunk searches defined in the sli.yaml file
-func getCustomQueries(k *keptnv2.Keptn, project string, stage string, service string) (map[string]string, error) {
+func getCustomQueries(k *keptnv2.Keptn, project string, stage string, service string) (map[string]SLIDefinition, error) {
 	log.Println("Checking for custom SLI queries")
 
-	customQueries, err := k.GetSLIConfiguration(project, stage, service, sliFileUri)
+	customQueries, err := getSLIDefinitions(k, project, stage, service)
 	if err != nil {
 		return nil, err
 	}
@@ -316,23 +376,26 @@ func getCustomQueries(k *keptnv2.Keptn, project string, stage string, service st
 	return customQueries, nil
 }
 
-// Returns the name of the splunk search result
-func getResultFieldName(searchQuery string) (string, error) {
-	//returns the first word after "stats" in the search
-	if strings.Contains(searchQuery, "stats") {
-		startIndex := strings.Index(searchQuery, "stats") + 4
-		i := 0
-		for {
-			i = i + 1
-			if (startIndex+i == len(searchQuery) || searchQuery[startIndex+i] == " "[0]) && searchQuery[startIndex+i-1] != "s"[0] {
-				return searchQuery[startIndex+2 : startIndex+i], nil
-			}
-			if startIndex+i == len(searchQuery) {
-				break
-			}
-		}
+// Returns the name of the field of the splunk search result compared in the alert condition
+//
+//	it is the resultField of the indicator if set, otherwise it is read from the commands of the query
+//	as parsed by splunk, or as written if splunk could not parse it
+func getResultFieldName(definition SLIDefinition, parsed *spl.ParsedQuery) (string, error) {
+	if definition.ResultField != "" {
+		return definition.ResultField, nil
+	}
+
+	var resultField string
+	var err error
+	if parsed != nil && len(parsed.Commands) > 0 {
+		resultField, err = parsed.ResultField()
+	} else {
+		resultField, err = spl.ResultField(definition.Query)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not determine the result field of the query %s, set the resultField of the indicator in %s: %w", definition.Query, sliFileUri, err)
 	}
-	return "", fmt.Errorf("no aggregation function found in the search query")
+	return resultField, nil
 }
 
 // Appends "search", "result name" and criteria
@@ -81,7 +81,7 @@ func TestHandleConfigureMonitoringTriggeredEvent(t *testing.T) {
 	}
 	client := utils.ConnectToSplunk(*splunkCreds, true)
 	data.ConfigureMonitoring.Type = "splunk"
-	err = HandleConfigureMonitoringTriggeredEvent(ddKeptn, *incomingEvent, data, env, client, false)
+	err = HandleConfigureMonitoringTriggeredEvent(ddKeptn, *incomingEvent, data, env, client)
 
 	if err != nil {
 		t.Fatalf("Error: %v", err)
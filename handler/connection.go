@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	"github.com/keptn/go-utils/pkg/api/models"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	logger "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const connectionFileUri = "splunk/connection.yaml"
+const credentialsFileUri = "splunk/credentials.yaml"
+
+// label of the events selecting the splunk connection
+const ConnectionLabel = "splunk-connection"
+
+// content of the splunk/connection.yaml resource
+type connectionConfig struct {
+	// name of the connection in the connections file
+	Connection string `yaml:"connection"`
+}
+
+// content of the splunk/credentials.yaml resource
+type credentialsConfig struct {
+	// name of the kubernetes secret holding the credentials, must start with splunk-credentials-
+	SecretName string `yaml:"secretName"`
+}
+
+// splunk instances the service can use, nil if only the default client is configured
+var splunkConnections *utils.SplunkConnections
+
+// credentials brought by the projects in kubernetes secrets, nil if disabled
+var projectCredentials *utils.ProjectCredentials
+
+// SetSplunkConnections registers the splunk instances selectable per project, stage or service
+func SetSplunkConnections(connections *utils.SplunkConnections) {
+	splunkConnections = connections
+}
+
+// SetProjectCredentials enables the credentials read per project from kubernetes secrets
+func SetProjectCredentials(credentials *utils.ProjectCredentials) {
+	projectCredentials = credentials
+}
+
+// Returns the client of the splunk instance configured for the project, stage and service
+//
+//	the connection is taken from the splunk-connection label of the event if set,
+//	then from the kubernetes secret of the project if project credentials are enabled,
+//	then from the splunk/connection.yaml resource of the service, the stage or the project (the most specific wins)
+//	and falls back to the default client
+func selectSplunkClient(k *keptnv2.Keptn, project string, stage string, service string, labels map[string]string, defaultClient *splunk.SplunkClient) (*splunk.SplunkClient, error) {
+
+	if splunkConnections == nil && projectCredentials == nil {
+		return defaultClient, nil
+	}
+
+	connectionName := labels[ConnectionLabel]
+	if connectionName == "" && projectCredentials != nil {
+		client, err := getProjectClient(k, project, stage, service)
+		if err != nil || client != nil {
+			return client, err
+		}
+	}
+
+	if connectionName == "" && splunkConnections != nil {
+		var err error
+		connectionName, err = getConnectionName(k, project, stage, service)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if connectionName == "" {
+		return defaultClient, nil
+	}
+	if splunkConnections == nil {
+		return nil, fmt.Errorf("no splunk connection named %s", connectionName)
+	}
+
+	logger.Infof("Using splunk connection %s for project %s, stage %s and service %s", connectionName, project, stage, service)
+	return splunkConnections.Get(connectionName)
+}
+
+// Returns the client of the credentials secret referenced by the splunk/credentials.yaml resources
+// or of the secret splunk-credentials-<project>, nil if there is none
+func getProjectClient(k *keptnv2.Keptn, project string, stage string, service string) (*splunk.SplunkClient, error) {
+
+	var config credentialsConfig
+	err := getSplunkResource(k, project, stage, service, credentialsFileUri, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	secretName := config.SecretName
+	if secretName == "" {
+		secretName = utils.ProjectSecretName(project)
+	}
+
+	client, err := projectCredentials.Client(context.Background(), secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the splunk credentials of project %s: %w", project, err)
+	}
+	if client != nil {
+		logger.Infof("Using the splunk credentials of the secret %s for project %s", secretName, project)
+	} else if config.SecretName != "" {
+		return nil, fmt.Errorf("the secret %s referenced by %s does not exist", secretName, credentialsFileUri)
+	}
+
+	return client, nil
+}
+
+// Reads the name of the connection from the splunk/connection.yaml resources
+func getConnectionName(k *keptnv2.Keptn, project string, stage string, service string) (string, error) {
+
+	var config connectionConfig
+	err := getSplunkResource(k, project, stage, service, connectionFileUri, &config)
+
+	return config.Connection, err
+}
+
+// Unmarshals the most specific resource of the service, the stage or the project into config, which is left untouched if there is none
+func getSplunkResource(k *keptnv2.Keptn, project string, stage string, service string, resourceUri string, config interface{}) error {
+
+	getResources := []func() (*models.Resource, error){}
+	if project != "" && stage != "" && service != "" {
+		getResources = append(getResources, func() (*models.Resource, error) {
+			return k.ResourceHandler.GetServiceResource(project, stage, service, resourceUri)
+		})
+	}
+	if project != "" && stage != "" {
+		getResources = append(getResources, func() (*models.Resource, error) {
+			return k.ResourceHandler.GetStageResource(project, stage, resourceUri)
+		})
+	}
+	if project != "" {
+		getResources = append(getResources, func() (*models.Resource, error) {
+			return k.ResourceHandler.GetProjectResource(project, resourceUri)
+		})
+	}
+
+	for _, getResource := range getResources {
+		resource, err := getResource()
+		if err != nil {
+			if strings.Contains(strings.ToLower(err.Error()), "resource not found") {
+				continue
+			}
+			return fmt.Errorf("failed to fetch %s from config repo: %w", resourceUri, err)
+		}
+		if resource == nil || strings.TrimSpace(resource.ResourceContent) == "" {
+			continue
+		}
+
+		err = yaml.Unmarshal([]byte(resource.ResourceContent), config)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", resourceUri, err)
+		}
+		return nil
+	}
+
+	return nil
+}
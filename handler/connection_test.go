@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Tests the selectSplunkClient function
+func TestSelectSplunkClient(t *testing.T) {
+
+	connectionFile := filepath.Join(t.TempDir(), "connection.yaml")
+	err := os.WriteFile(connectionFile, []byte("connection: prod\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Building a mock resource service server returning the connection of the service
+	var getResponses, paths []string
+	err = updateGetResponses(&getResponses, &paths, connectionFile, connectionFileUri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resourceServiceServer := utils.MultitpleMockRequest(getResponses, nil, paths, false)
+	defer resourceServiceServer.Close()
+
+	ddKeptn, _, err := initializeTestObjects(getSliTriggeredEventFile, resourceServiceServer.URL+"/api/resource-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaultClient := splunk.NewClientAuthenticatedByToken(&http.Client{}, "default", "8089", "token", false)
+	prodClient := splunk.NewClientAuthenticatedByToken(&http.Client{}, "prod", "8089", "token", false)
+	devClient := splunk.NewClientAuthenticatedByToken(&http.Client{}, "dev", "8089", "token", false)
+
+	// without registry, the default client is always used
+	client, err := selectSplunkClient(ddKeptn, project, stage, service, nil, defaultClient)
+	if err != nil || client != defaultClient {
+		t.Fatalf("Expected the default client but got %v : %v", client, err)
+	}
+
+	connections := utils.NewSplunkConnections(defaultClient)
+	connections.Add("prod", prodClient)
+	connections.Add("dev", devClient)
+	SetSplunkConnections(connections)
+	defer SetSplunkConnections(nil)
+
+	// the connection is read from the splunk/connection.yaml resource
+	client, err = selectSplunkClient(ddKeptn, project, stage, service, nil, defaultClient)
+	if err != nil || client != prodClient {
+		t.Fatalf("Expected the prod client but got %v : %v", client, err)
+	}
+
+	// the label of the event takes precedence
+	client, err = selectSplunkClient(ddKeptn, project, stage, service, map[string]string{ConnectionLabel: "dev"}, defaultClient)
+	if err != nil || client != devClient {
+		t.Fatalf("Expected the dev client but got %v : %v", client, err)
+	}
+
+	_, err = selectSplunkClient(ddKeptn, project, stage, service, map[string]string{ConnectionLabel: "unknown"}, defaultClient)
+	if err == nil {
+		t.Fatal("Expected an error for an unknown connection")
+	}
+}
+
+// Tests that the credentials of the project are read from its kubernetes secret
+func TestSelectSplunkClientProjectCredentials(t *testing.T) {
+
+	credentialsFile := filepath.Join(t.TempDir(), "credentials.yaml")
+	err := os.WriteFile(credentialsFile, []byte("secretName: splunk-credentials-team-a\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var getResponses, paths []string
+	err = updateGetResponses(&getResponses, &paths, credentialsFile, credentialsFileUri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resourceServiceServer := utils.MultitpleMockRequest(getResponses, nil, paths, false)
+	defer resourceServiceServer.Close()
+
+	ddKeptn, _, err := initializeTestObjects(getSliTriggeredEventFile, resourceServiceServer.URL+"/api/resource-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kube := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "splunk-credentials-team-a", Namespace: "keptn"},
+		Data:       map[string][]byte{"SP_HOST": []byte("splunk-team-a"), "SP_API_TOKEN": []byte("teamToken")},
+	})
+	SetProjectCredentials(utils.NewProjectCredentials(kube, "keptn", utils.EnvConfig{SplunkPort: "8089", SplunkTLSMinVersion: "1.2"}))
+	defer SetProjectCredentials(nil)
+
+	defaultClient := splunk.NewClientAuthenticatedByToken(&http.Client{}, "default", "8089", "token", false)
+
+	client, err := selectSplunkClient(ddKeptn, project, stage, service, nil, defaultClient)
+	if err != nil || client.Host != "splunk-team-a" || client.Token != "teamToken" || client.Port != "8089" {
+		t.Fatalf("Expected the client of the project secret but got %v : %v", client, err)
+	}
+
+	// the client is reused while the secret does not change
+	other, err := selectSplunkClient(ddKeptn, project, stage, service, nil, defaultClient)
+	if err != nil || other != client {
+		t.Fatalf("Expected the cached client but got %v : %v", other, err)
+	}
+
+	// a referenced secret which does not exist is an error
+	SetProjectCredentials(utils.NewProjectCredentials(fake.NewSimpleClientset(), "keptn", utils.EnvConfig{}))
+	_, err = selectSplunkClient(ddKeptn, project, stage, service, nil, defaultClient)
+	if err == nil {
+		t.Fatal("Expected an error for a missing secret")
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2" // make sure to use v2 cloudevents here
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	logger "github.com/sirupsen/logrus"
+)
+
+// labels of the evaluation holding the version of the service, the first one set is used
+var versionLabels = []string{"version", "buildId"}
+
+// HandleEvaluationFinishedEvent sends the value and the score of each indicator of the evaluation
+// and the score of the evaluation to the HTTP Event Collector of splunk as metrics
+func HandleEvaluationFinishedEvent(batcher *hec.Batcher, incomingEvent cloudevents.Event, data *keptnv2.EvaluationFinishedEventData, envConfig utils.EnvConfig) error {
+
+	logger.Infof("Sending the results of evaluation %s to splunk as metrics", incomingEvent.ID())
+
+	metricTime := incomingEvent.Time()
+	if timeEnd, err := time.Parse(time.RFC3339, data.Evaluation.TimeEnd); err == nil {
+		metricTime = timeEnd
+	}
+	if metricTime.IsZero() {
+		metricTime = time.Now()
+	}
+
+	var shkeptncontext string
+	_ = incomingEvent.ExtensionAs("shkeptncontext", &shkeptncontext)
+
+	dimensions := map[string]interface{}{
+		"project":       data.Project,
+		"stage":         data.Stage,
+		"service":       data.Service,
+		"keptn_context": shkeptncontext,
+		"evaluation_id": incomingEvent.ID(),
+		"result":        data.Evaluation.Result,
+		"version":       "",
+	}
+	for _, label := range versionLabels {
+		if data.Labels[label] != "" {
+			dimensions["version"] = data.Labels[label]
+			break
+		}
+	}
+	for name, value := range dimensions {
+		if value == "" {
+			delete(dimensions, name)
+		}
+	}
+
+	newMetric := func(measures map[string]float64, extraDimensions map[string]interface{}) hec.Event {
+		fields := map[string]interface{}{}
+		for name, value := range dimensions {
+			fields[name] = value
+		}
+		for name, value := range extraDimensions {
+			fields[name] = value
+		}
+		for name, value := range measures {
+			fields["metric_name:"+name] = value
+		}
+		return hec.Event{
+			Time:       float64(metricTime.UnixMilli()) / 1000,
+			Source:     envConfig.SplunkHECSource,
+			SourceType: envConfig.SplunkHECMetricsSourceType,
+			Index:      envConfig.SplunkHECMetricsIndex,
+			Event:      "metric",
+			Fields:     fields,
+		}
+	}
+
+	utils.ForwardHECEvent(batcher, newMetric(map[string]float64{"keptn.evaluation.score": data.Evaluation.Score}, nil))
+
+	for _, indicator := range data.Evaluation.IndicatorResults {
+		if indicator == nil || indicator.Value == nil || indicator.Value.Metric == "" {
+			continue
+		}
+		measures := map[string]float64{"keptn.sli.score": indicator.Score}
+		// the value of a failed indicator is meaningless
+		if indicator.Value.Success {
+			measures["keptn.sli.value"] = indicator.Value.Value
+		}
+		utils.ForwardHECEvent(batcher, newMetric(measures, map[string]interface{}{
+			"sli":        indicator.Value.Metric,
+			"sli_status": indicator.Status,
+			"key_sli":    fmt.Sprint(indicator.KeySLI),
+		}))
+	}
+
+	return nil
+}
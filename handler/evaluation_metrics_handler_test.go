@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+// Tests that the HandleEvaluationFinishedEvent function sends the results of the evaluation as metrics
+func TestHandleEvaluationFinishedEvent(t *testing.T) {
+	var received []hec.Event
+	collector, batcher, envConfig := buildMockCollector(t, &received)
+	defer collector.Close()
+
+	incomingEvent := readCloudEvent(t, evaluationFinishedEventFile)
+	data := &keptnv2.EvaluationFinishedEventData{}
+	err := incomingEvent.DataAs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Labels = map[string]string{"buildId": "0.1.1"}
+
+	err = HandleEvaluationFinishedEvent(batcher, incomingEvent, data, envConfig)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	err = batcher.Flush()
+	if err != nil || len(received) != 2 {
+		t.Fatalf("Expected the evaluation and indicator metrics but got %d : %v", len(received), err)
+	}
+
+	evaluation, indicator := received[0], received[1]
+	if evaluation.Event != "metric" || evaluation.Index != "keptn_metrics" || evaluation.SourceType != "keptn:metric" || evaluation.Time != 1610723385 {
+		t.Fatalf("Unexpected metric event %+v", evaluation)
+	}
+	if evaluation.Fields["metric_name:keptn.evaluation.score"] != 100.0 || evaluation.Fields["version"] != "0.1.1" || evaluation.Fields["stage"] != "hardening" {
+		t.Fatalf("Unexpected evaluation metric %v", evaluation.Fields)
+	}
+	if indicator.Fields["sli"] != "http_response_time_seconds_main_page_sum" || indicator.Fields["metric_name:keptn.sli.value"] != 0.21 || indicator.Fields["metric_name:keptn.sli.score"] != 1.0 {
+		t.Fatalf("Unexpected indicator metric %v", indicator.Fields)
+	}
+}
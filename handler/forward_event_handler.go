@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2" // make sure to use v2 cloudevents here
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	logger "github.com/sirupsen/logrus"
+)
+
+const keptnEventTypePrefix = "sh.keptn.event."
+
+// content of the events sent to the HTTP Event Collector
+type forwardedEvent struct {
+	Type           string      `json:"type"`
+	ID             string      `json:"id"`
+	Source         string      `json:"source"`
+	Time           string      `json:"time,omitempty"`
+	ShKeptnContext string      `json:"shkeptncontext,omitempty"`
+	TriggeredID    string      `json:"triggeredid,omitempty"`
+	Data           interface{} `json:"data,omitempty"`
+}
+
+// IsForwardedEvent returns true if the event type is the finished event of one of the comma separated tasks,
+// e.g. sh.keptn.event.deployment.finished or the sequence event sh.keptn.event.production.remediation.finished for remediation
+func IsForwardedEvent(eventType string, tasks string) bool {
+	if !strings.HasPrefix(eventType, keptnEventTypePrefix) || !strings.HasSuffix(eventType, ".finished") {
+		return false
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(eventType, keptnEventTypePrefix), ".finished")
+	task := name[strings.LastIndex(name, ".")+1:]
+
+	for _, forwardedTask := range strings.Split(tasks, ",") {
+		if strings.TrimSpace(forwardedTask) == task {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleForwardedEvent sends the keptn event to the HTTP Event Collector of splunk
+func HandleForwardedEvent(batcher *hec.Batcher, incomingEvent cloudevents.Event, envConfig utils.EnvConfig) error {
+
+	logger.Infof("Forwarding %s event %s to splunk", incomingEvent.Type(), incomingEvent.ID())
+
+	content := forwardedEvent{
+		Type:   incomingEvent.Type(),
+		ID:     incomingEvent.ID(),
+		Source: incomingEvent.Source(),
+	}
+	if !incomingEvent.Time().IsZero() {
+		content.Time = incomingEvent.Time().Format("2006-01-02T15:04:05.000Z07:00")
+	}
+	_ = incomingEvent.ExtensionAs("shkeptncontext", &content.ShKeptnContext)
+	_ = incomingEvent.ExtensionAs("triggeredid", &content.TriggeredID)
+
+	if len(incomingEvent.Data()) > 0 {
+		err := json.Unmarshal(incomingEvent.Data(), &content.Data)
+		if err != nil {
+			return fmt.Errorf("could not parse the data of the event %s: %w", incomingEvent.ID(), err)
+		}
+	}
+
+	// project, stage, service, status and result are indexed to correlate the events with the logs
+	eventData := keptnv2.EventData{}
+	_ = incomingEvent.DataAs(&eventData)
+	fields := map[string]interface{}{}
+	for name, value := range map[string]string{
+		"project": eventData.Project,
+		"stage":   eventData.Stage,
+		"service": eventData.Service,
+		"status":  string(eventData.Status),
+		"result":  string(eventData.Result),
+	} {
+		if value != "" {
+			fields[name] = value
+		}
+	}
+
+	event := hec.Event{
+		Source:     envConfig.SplunkHECSource,
+		SourceType: envConfig.SplunkHECSourceType,
+		Index:      envConfig.SplunkHECIndex,
+		Event:      content,
+		Fields:     fields,
+	}
+	if !incomingEvent.Time().IsZero() {
+		event.Time = float64(incomingEvent.Time().UnixMilli()) / 1000
+	}
+
+	utils.ForwardHECEvent(batcher, event)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+const evaluationFinishedEventFile = "../test/events/evaluation.finished.json"
+
+// Tests the IsForwardedEvent function
+func TestIsForwardedEvent(t *testing.T) {
+	tasks := "deployment, evaluation,remediation"
+
+	forwarded := map[string]bool{
+		"sh.keptn.event.deployment.finished":             true,
+		"sh.keptn.event.evaluation.finished":             true,
+		"sh.keptn.event.production.remediation.finished": true,
+		"sh.keptn.event.deployment.triggered":            false,
+		"sh.keptn.event.get-sli.finished":                false,
+		"sh.keptn.event.production.delivery.finished":    false,
+	}
+	for eventType, expected := range forwarded {
+		if IsForwardedEvent(eventType, tasks) != expected {
+			t.Errorf("Expected IsForwardedEvent to return %v for %s", expected, eventType)
+		}
+	}
+}
+
+// Builds a fake HTTP Event Collector and a batcher sending the events to it
+func buildMockCollector(t *testing.T, received *[]hec.Event) (*httptest.Server, *hec.Batcher, utils.EnvConfig) {
+	collector := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		for decoder.More() {
+			var event hec.Event
+			if err := decoder.Decode(&event); err != nil {
+				t.Errorf("Invalid event : %v", err)
+				return
+			}
+			*received = append(*received, event)
+		}
+		_, _ = w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+
+	envConfig := utils.EnvConfig{
+		SplunkHECURL:               collector.URL,
+		SplunkHECToken:             "hecToken",
+		SplunkHECIndex:             "keptn",
+		SplunkHECSourceType:        "keptn:event",
+		SplunkHECSource:            "keptn",
+		SplunkHECMetricsIndex:      "keptn_metrics",
+		SplunkHECMetricsSourceType: "keptn:metric",
+		SplunkHECBatchSize:         100,
+		SplunkSkipSSLVerify:        true,
+		SplunkTLSMinVersion:        "1.2",
+	}
+	batcher, err := utils.NewHECBatcher(envConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return collector, batcher, envConfig
+}
+
+// reads the json event file and convert its content into an event
+func readCloudEvent(t *testing.T, eventFile string) cloudevents.Event {
+	content, err := os.ReadFile(eventFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	incomingEvent := cloudevents.NewEvent()
+	err = json.Unmarshal(content, &incomingEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return incomingEvent
+}
+
+// Tests that the HandleForwardedEvent function sends the event to the HTTP Event Collector
+func TestHandleForwardedEvent(t *testing.T) {
+	var received []hec.Event
+	collector, batcher, envConfig := buildMockCollector(t, &received)
+	defer collector.Close()
+
+	err := HandleForwardedEvent(batcher, readCloudEvent(t, evaluationFinishedEventFile), envConfig)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	err = batcher.Flush()
+	if err != nil || len(received) != 1 {
+		t.Fatalf("Expected one event to be sent but got %d : %v", len(received), err)
+	}
+
+	if received[0].Index != "keptn" || received[0].SourceType != "keptn:event" || received[0].Time != 1610723402.006 {
+		t.Fatalf("Unexpected event metadata %+v", received[0])
+	}
+	if received[0].Fields["project"] != "podtatohead" || received[0].Fields["result"] != "pass" {
+		t.Fatalf("Unexpected indexed fields %v", received[0].Fields)
+	}
+	event, ok := received[0].Event.(map[string]interface{})
+	if !ok || event["type"] != "sh.keptn.event.evaluation.finished" || event["triggeredid"] != "5afa758e-697c-4496-8deb-4d7cc1c93967" || event["data"] == nil {
+		t.Fatalf("Unexpected event %v", received[0].Event)
+	}
+}
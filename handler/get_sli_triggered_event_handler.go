@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
 	splunkjobs "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/jobs"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
 	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2" // make sure to use v2 cloudevents here
@@ -16,6 +20,36 @@ const sliFileUri = "splunk/sli.yaml"
 const KeptnSuffix = "keptn"
 const serviceName = "splunk-service"
 
+// interval at which the state of the searches of the SLIs is checked
+const sliSearchPollInterval = time.Second
+
+// searches of the SLIs still running after this timeout are cancelled in splunk
+var sliSearchTimeout = 60 * time.Second
+
+// values of the SLI searches shared by the get-sli events
+var sliCache = utils.NewSLICache(30 * time.Second)
+
+// time zone of the start and end of the evaluations given without time zone
+var timeLocation = time.UTC
+
+// parses a query with the search parser of splunk, replaced in the tests
+var parseQuery = spl.Parse
+
+// Sets the timeout of the searches of the SLIs
+func SetSLISearchTimeout(timeout time.Duration) {
+	sliSearchTimeout = timeout
+}
+
+// Sets the time during which the values of the SLIs are reused, 0 disables the cache
+func SetSLICacheTTL(ttl time.Duration) {
+	sliCache = utils.NewSLICache(ttl)
+}
+
+// Sets the time zone of the start and end of the evaluations given without time zone
+func SetTimeLocation(location *time.Location) {
+	timeLocation = location
+}
+
 // HandleGetSliTriggeredEvent handles get-sli.triggered events if SLIProvider == splunk
 func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevents.Event, data *keptnv2.GetSLITriggeredEventData, client *splunk.SplunkClient) error {
 	var shkeptncontext string
@@ -49,13 +83,29 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 		labels = make(map[string]string)
 	}
 
+	// select the splunk instance configured for the project, stage or service
+	client, err = selectSplunkClient(ddKeptn, data.Project, data.Stage, data.Service, labels, client)
+	if err != nil {
+		err := fmt.Errorf("failed to select the splunk connection: %w", err)
+		logger.Error(err)
+
+		_, _ = ddKeptn.SendTaskFinishedEvent(&keptnv2.EventData{
+			Status:  keptnv2.StatusErrored,
+			Result:  keptnv2.ResultFailed,
+			Labels:  labels,
+			Message: err.Error(),
+		}, serviceName)
+
+		return err
+	}
+
 	// Step 5 - get SLI Config File
 	// Get SLI File from splunk subdirectory of the config repo - to add the file use:
 	//   keptn add-resource --project=PROJECT --stage=STAGE --service=SERVICE --resource=my-sli-config.yaml  --resourceUri=splunk/sli.yaml
-	sliConfig, err := ddKeptn.GetSLIConfiguration(data.Project, data.Stage, data.Service, sliFileUri)
+	sliConfig, err := getSLIDefinitions(ddKeptn, data.Project, data.Stage, data.Service)
 	// FYI you do not need to "fail" if sli.yaml is missing, you can also assume smart defaults like we do
 	// in keptn-contrib/dynatrace-service and ECL2022PAI01/splunk-service
-	logger.Infof("SLI Config: %s", sliConfig)
+	logger.Infof("SLI Config: %v", sliConfig)
 	if err != nil {
 		// failed to fetch sli config file
 		err := fmt.Errorf("failed to fetch SLI file %s from config repo: %w", sliFileUri, err)
@@ -84,6 +134,10 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 		if err != nil {
 			break
 		}
+		// the indicator is skipped by its no-data policy
+		if sliResult == nil {
+			continue
+		}
 
 		sliResults = append(sliResults, sliResult)
 	}
@@ -123,21 +177,54 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 }
 
 // Executes the splunk search and return the metric value
-func handleSpecificSLI(client *splunk.SplunkClient, indicatorName string, data *keptnv2.GetSLITriggeredEventData, sliConfig map[string]string) (*keptnv2.SLIResult, error) {
+//
+//	the result is nil if the search returned no result and the indicator is skipped by its no-data policy
+func handleSpecificSLI(client *splunk.SplunkClient, indicatorName string, data *keptnv2.GetSLITriggeredEventData, sliConfig map[string]SLIDefinition) (*keptnv2.SLIResult, error) {
 
-	query := sliConfig[indicatorName]
-	params := splunkjobs.SearchParams{
-		SearchQuery:  query,
-		EarliestTime: data.GetSLI.Start,
-		LatestTime:   data.GetSLI.End,
+	definition := sliConfig[indicatorName]
+	search, err := buildSLISearch(indicatorName, definition, data.GetSLI.Start, data.GetSLI.End)
+	if err != nil {
+		return nil, err
 	}
+	earliestTime, latestTime := search.Params.EarliestTime, search.Params.LatestTime
 
-	// take the time range from the sli file if it is set
-	params.EarliestTime, params.LatestTime, params.SearchQuery = utils.RetrieveQueryTimeRange(params.EarliestTime, params.LatestTime, params.SearchQuery)
+	// the saved search is dispatched with the time range of the event
+	if search.SavedSearch != "" {
+		logger.Infof("saved search dispatched in splunk: %v, from: %v, to: %v", search.SavedSearch, earliestTime, latestTime)
+
+		// the value of a saved search returning several fields is read from the resultField of the indicator
+		key := utils.SLICacheKey(client, "| savedsearch "+spl.Quote(search.SavedSearch), definition.ResultField, earliestTime, latestTime)
+		sliValue, err := sliCache.Get(key, func() (float64, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), sliSearchTimeout)
+			defer cancel()
+			return splunkjobs.GetMetricFromSavedSearchWithContext(ctx, client, search.SavedSearch, earliestTime, latestTime, definition.ResultField, sliSearchPollInterval)
+		})
+		if errors.Is(err, splunkjobs.ErrNoResult) {
+			return noDataResult(indicatorName, definition, fmt.Errorf("no result for the saved search: %v : %w", search.SavedSearch, err))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting value for the saved search: %v : %w", search.SavedSearch, err)
+		}
+		return newSLIResult(indicatorName, sliValue), nil
+	}
+
+	params := search.Params
 	logger.Infof("actual query sent to splunk: %v, from: %v, to: %v", params.SearchQuery, params.EarliestTime, params.LatestTime)
 
-	if query == "" {
-		return nil, fmt.Errorf("no query found for indicator %s", indicatorName)
+	parsed, err := preflightQuery(client, params.SearchQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query for indicator %s: %w", indicatorName, err)
+	}
+
+	// the value is read from the result field of the indicator, or from the single field of the result,
+	// the field guessed from the query only choosing among several fields
+	field := splunkjobs.MetricField{Name: definition.ResultField}
+	if field.Name == "" {
+		field.Name, err = getResultFieldName(definition, parsed)
+		if err != nil {
+			logger.Debugf("No result field for indicator %s, reading the single field of its result: %v", indicatorName, err)
+		}
+		field.Inferred = true
 	}
 
 	spReq := splunkjobs.SearchRequest{
@@ -145,12 +232,67 @@ func handleSpecificSLI(client *splunk.SplunkClient, indicatorName string, data *
 		Headers: map[string]string{},
 	}
 
-	// get the metric we want
-	sliValue, err := splunkjobs.GetMetricFromNewJob(client, &spReq)
+	// get the metric we want, the search is cancelled if it takes too long and is shared with the identical SLIs
+	keyField := field.Name
+	if field.Inferred {
+		keyField = "~" + keyField
+	}
+	key := utils.SLICacheKey(client, params.SearchQuery, keyField, params.EarliestTime, params.LatestTime)
+	sliValue, err := sliCache.Get(key, func() (float64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), sliSearchTimeout)
+		defer cancel()
+		return splunkjobs.GetMetricFromNewJobWithContext(ctx, client, &spReq, field, sliSearchPollInterval)
+	})
+	if errors.Is(err, splunkjobs.ErrNoResult) {
+		return noDataResult(indicatorName, definition, fmt.Errorf("no result for the query: %v : %w", spReq.Params.SearchQuery, err))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error getting value for the query: %v : %w", spReq.Params.SearchQuery, err)
 	}
 
+	return newSLIResult(indicatorName, sliValue), nil
+}
+
+// search run for an indicator
+type sliSearch struct {
+	// saved search dispatched instead of the query if set
+	SavedSearch string
+	// query and time range of the search, the time modifiers of the query taking precedence over the time range of the event
+	Params splunkjobs.SearchParams
+}
+
+// Builds the search of an indicator for the time range of a get-sli event
+func buildSLISearch(indicatorName string, definition SLIDefinition, start string, end string) (*sliSearch, error) {
+
+	// the times of the event are sent as epoch times, whatever the time format of splunk
+	earliestTime, latestTime, err := utils.ToSplunkTimeRange(start, end, definition.Offset, timeLocation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time range for indicator %s: %w", indicatorName, err)
+	}
+
+	if definition.SavedSearch != "" {
+		return &sliSearch{
+			SavedSearch: definition.SavedSearch,
+			Params:      splunkjobs.SearchParams{EarliestTime: earliestTime, LatestTime: latestTime},
+		}, nil
+	}
+
+	if definition.Query == "" {
+		return nil, fmt.Errorf("no query found for indicator %s", indicatorName)
+	}
+	params := splunkjobs.SearchParams{
+		SearchQuery:  definition.Query,
+		EarliestTime: earliestTime,
+		LatestTime:   latestTime,
+	}
+
+	// take the time range from the sli file if it is set
+	params.EarliestTime, params.LatestTime, params.SearchQuery = utils.RetrieveQueryTimeRange(params.EarliestTime, params.LatestTime, params.SearchQuery)
+	return &sliSearch{Params: params}, nil
+}
+
+// Builds the successful result of an indicator
+func newSLIResult(indicatorName string, sliValue float64) *keptnv2.SLIResult {
 	logger.Infof("response from the metrics api: %v", sliValue)
 
 	sliResult := &keptnv2.SLIResult{
@@ -160,5 +302,20 @@ func handleSpecificSLI(client *splunk.SplunkClient, indicatorName string, data *
 	}
 	logger.WithFields(logger.Fields{"indicatorName": indicatorName}).Infof("SLI result from the metrics api: %v", sliResult)
 
-	return sliResult, nil
+	return sliResult
+}
+
+// Checks the syntax of the query with the search parser of splunk before running it and returns the parsed query
+//
+//	only a query rejected by the parser is an error, the parsed query is nil if the parser could not be reached
+func preflightQuery(client *splunk.SplunkClient, query string) (*spl.ParsedQuery, error) {
+	parsed, err := parseQuery(client, query)
+	if spl.IsInvalidQuery(err) {
+		return nil, err
+	}
+	if err != nil {
+		logger.Warnf("Could not validate the query %s: %v", query, err)
+		return nil, nil
+	}
+	return parsed, nil
 }
@@ -14,6 +14,7 @@ import (
 
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
 	splunktest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
 	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2" // make sure to use v2 cloudevents here
@@ -84,8 +85,8 @@ func initializeTestObjects(eventFileName string, resourceServiceUrl string) (*ke
 func TestHandleSpecificSli(t *testing.T) {
 	indicatorName := "test"
 	data := &keptnv2.GetSLITriggeredEventData{}
-	sliConfig := make(map[string]string, 1)
-	sliConfig[indicatorName] = "test"
+	sliConfig := make(map[string]SLIDefinition, 1)
+	sliConfig[indicatorName] = SLIDefinition{Query: "test"}
 
 	//Building a mock splunk server returning default responses when getting  get and post requests
 
@@ -119,6 +120,51 @@ func TestHandleSpecificSli(t *testing.T) {
 	}
 }
 
+func TestHandleSpecificSliInvalidQuery(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{}
+	sliConfig := map[string]SLIDefinition{"errors": {Query: "index=main | stat count"}}
+
+	splunkServer := utils.BuildMockSplunkServer(defaultSplunkTestResult)
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1], strings.Split(splunkServer.URL, ":")[2], "apiToken", true)
+
+	defer func() { parseQuery = spl.Parse }()
+
+	// the search parser rejects the query
+	parseQuery = func(client *splunk.SplunkClient, query string) (*spl.ParsedQuery, error) {
+		return nil, &spl.InvalidQueryError{Query: query, Messages: []string{"Unknown search command 'stat'."}}
+	}
+	_, err := handleSpecificSLI(client, "errors", data, sliConfig)
+	if !spl.IsInvalidQuery(err) {
+		t.Fatalf("Expected an invalid query error but got %v", err)
+	}
+
+	// the search parser could not be reached, the query is run anyway
+	parseQuery = func(client *splunk.SplunkClient, query string) (*spl.ParsedQuery, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	sliResult, err := handleSpecificSLI(client, "errors", data, sliConfig)
+	if err != nil || sliResult.Value != float64(defaultSplunkTestResult) {
+		t.Fatalf("Expected the query to be run but got %v : %v", sliResult, err)
+	}
+}
+
+// Tests that an SLI is not searched when the start of the evaluation is after its end
+func TestHandleSpecificSliInvalidTimeRange(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{}
+	data.GetSLI.Start = "2022-07-05T12:31:40.000Z"
+	data.GetSLI.End = "2022-07-05T12:26:40.000Z"
+	sliConfig := map[string]SLIDefinition{"errors": {Query: "index=main | stats count"}}
+
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, "localhost", "8089", "apiToken", true)
+
+	_, err := handleSpecificSLI(client, "errors", data, sliConfig)
+	if err == nil || !strings.Contains(err.Error(), "invalid time range") {
+		t.Fatalf("Expected an invalid time range error but got %v", err)
+	}
+}
+
 // Tests the handleGetSliTriggered function
 // Tests the handleGetSliTriggered function
 func TestHandleGetSliTriggered(t *testing.T) {
@@ -208,6 +254,77 @@ func TestHandleGetSliTriggered(t *testing.T) {
 	}
 }
 
+// Tests that the value of a query returning several fields is read from the result field of the indicator
+func TestHandleSpecificSliSeveralFields(t *testing.T) {
+	splunkResponses := []map[string]interface{}{{
+		splunktest.GetJobStatus: `{"entry":[{"content":{"sid":"10","dispatchState":"DONE","isDone":true,"resultCount":1}}]}`,
+		http.MethodPost:         `{"sid": "10"}`,
+		http.MethodGet:          `{"results":[{"errors":"5","total":"200","rate":"0.025"}]}`,
+	}}
+	splunkServer := splunktest.MultitpleMockRequest(splunkResponses, true)
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		true,
+	)
+	query := "index=main | stats count(eval(status>=500)) AS errors, count AS total | eval rate=errors/total"
+	sliConfig := map[string]SLIDefinition{
+		"error_rate": {Query: query, ResultField: "rate"},
+		"errors":     {Query: query, ResultField: "errors"},
+	}
+
+	sliResult, err := handleSpecificSLI(client, "error_rate", &keptnv2.GetSLITriggeredEventData{}, sliConfig)
+	if err != nil || sliResult.Value != 0.025 {
+		t.Fatalf("Expected the value of the rate field but got %v : %v", sliResult, err)
+	}
+	// the indicators sharing the query do not share their value
+	sliResult, err = handleSpecificSLI(client, "errors", &keptnv2.GetSLITriggeredEventData{}, sliConfig)
+	if err != nil || sliResult.Value != 5 {
+		t.Fatalf("Expected the value of the errors field but got %v : %v", sliResult, err)
+	}
+}
+
+// Tests that a result whose single field is not the one guessed from the query is still read, even with the zero no-data policy
+func TestHandleSpecificSliInferredFieldMissing(t *testing.T) {
+	splunkResponses := []map[string]interface{}{{
+		splunktest.GetJobStatus: `{"entry":[{"content":{"sid":"10","dispatchState":"DONE","isDone":true,"resultCount":1}}]}`,
+		http.MethodPost:         `{"sid": "10"}`,
+		http.MethodGet:          `{"results":[{"errors":"42"}]}`,
+	}}
+	splunkServer := splunktest.MultitpleMockRequest(splunkResponses, true)
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		true,
+	)
+	sliConfig := map[string]SLIDefinition{
+		"inferred":   {Query: "index=main | stats count by host | stats sum(count) as total", NoData: "zero"},
+		"configured": {Query: "index=main | stats count by host | stats sum(count) as total", ResultField: "total", NoData: "zero"},
+	}
+
+	sliResult, err := handleSpecificSLI(client, "inferred", &keptnv2.GetSLITriggeredEventData{}, sliConfig)
+	if err != nil || sliResult.Value != 42 {
+		t.Fatalf("Expected the value of the single field but got %v : %v", sliResult, err)
+	}
+	// the result field of the indicator is not replaced, its absence is no data
+	sliResult, err = handleSpecificSLI(client, "configured", &keptnv2.GetSLITriggeredEventData{}, sliConfig)
+	if err != nil || sliResult.Value != 0 {
+		t.Fatalf("Expected the zero of the no-data policy but got %v : %v", sliResult, err)
+	}
+}
+
 // Builds a fake splunk server able to respond when we try to list fired alerts and instances of fired alerts
 func buildMockSplunkServer(t *testing.T) *httptest.Server {
 
@@ -224,11 +341,16 @@ func buildMockSplunkServer(t *testing.T) *httptest.Server {
 		"results":[{"theRequest":"` + fmt.Sprint(defaultSplunkTestResult) + `"}]
 	}`
 
+	jsonResponseJobStatus := `{
+		"entry":[{"content":{"sid":"10","dispatchState":"DONE","isDone":true,"resultCount":1}}]
+	}`
+
 	splunkResponses := make([]map[string]interface{}, 2)
 	splunkResponses[0] = map[string]interface{}{
-		"getAlertsNames": getAlertsNamesResponse,
-		http.MethodPost:  jsonResponsePOST,
-		http.MethodGet:   jsonResponseGET,
+		"getAlertsNames":        getAlertsNamesResponse,
+		splunktest.GetJobStatus: jsonResponseJobStatus,
+		http.MethodPost:         jsonResponsePOST,
+		http.MethodGet:          jsonResponseGET,
 	}
 	splunkServer := splunktest.MultitpleMockRequest(splunkResponses, true)
 
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	logger "github.com/sirupsen/logrus"
+)
+
+// what is done when the search of an indicator returns no result
+const (
+	// the get-sli fails
+	NoDataError = "error"
+	// the value of the indicator is 0
+	NoDataZero = "zero"
+	// the value of the indicator is its default value
+	NoDataDefault = "default"
+	// the indicator is left out of the results
+	NoDataSkip = "skip"
+)
+
+// no-data policy of the indicators which do not set one
+var noDataPolicy = NoDataError
+
+// default value of the indicators which do not set one
+var noDataDefaultValue float64
+
+// Sets the no-data policy and the default value of the indicators which do not set them
+func SetNoDataPolicy(policy string, defaultValue float64) error {
+	err := checkNoDataPolicy(policy)
+	if err != nil {
+		return err
+	}
+	noDataPolicy = policy
+	noDataDefaultValue = defaultValue
+	return nil
+}
+
+func checkNoDataPolicy(policy string) error {
+	switch policy {
+	case NoDataError, NoDataZero, NoDataDefault, NoDataSkip:
+		return nil
+	}
+	return fmt.Errorf("unknown no-data policy %s, expected %s, %s, %s or %s", policy, NoDataError, NoDataZero, NoDataDefault, NoDataSkip)
+}
+
+// Returns the result of an indicator whose search returned no result according to its no-data policy
+//
+//	the result is nil if the indicator is skipped and its message tells that its value was defaulted
+func noDataResult(indicatorName string, definition SLIDefinition, err error) (*keptnv2.SLIResult, error) {
+	policy := definition.NoData
+	if policy == "" {
+		policy = noDataPolicy
+	}
+
+	var value float64
+	switch policy {
+	case NoDataSkip:
+		logger.Infof("No data for indicator %s, the indicator is skipped", indicatorName)
+		return nil, nil
+	case NoDataZero:
+		value = 0
+	case NoDataDefault:
+		value = noDataDefaultValue
+		if definition.DefaultValue != nil {
+			value = *definition.DefaultValue
+		}
+	default:
+		return nil, err
+	}
+
+	logger.Infof("No data for indicator %s, its value is defaulted to %v", indicatorName, value)
+	return &keptnv2.SLIResult{
+		Metric:  indicatorName,
+		Value:   value,
+		Success: true,
+		Message: fmt.Sprintf("no data, the value is defaulted to %v by the %s no-data policy", value, policy),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Tests the results of the indicators without data according to their no-data policy and the one of the service
+func TestNoDataResult(t *testing.T) {
+	noResult := fmt.Errorf("no result found")
+	defaultValue := 0.99
+
+	defer func() { _ = SetNoDataPolicy(NoDataError, 0) }()
+
+	result, err := noDataResult("errors", SLIDefinition{}, noResult)
+	if err != noResult || result != nil {
+		t.Fatalf("Expected the error of the search but got %v : %v", result, err)
+	}
+
+	result, err = noDataResult("errors", SLIDefinition{NoData: NoDataZero}, noResult)
+	if err != nil || result.Value != 0 || !result.Success || !strings.Contains(result.Message, "no data") {
+		t.Fatalf("Expected a zero value marked as defaulted but got %+v : %v", result, err)
+	}
+
+	result, err = noDataResult("availability", SLIDefinition{NoData: NoDataDefault, DefaultValue: &defaultValue}, noResult)
+	if err != nil || result.Value != defaultValue || !strings.Contains(result.Message, "default") {
+		t.Fatalf("Expected the default value of the indicator but got %+v : %v", result, err)
+	}
+
+	result, err = noDataResult("errors", SLIDefinition{NoData: NoDataSkip}, noResult)
+	if err != nil || result != nil {
+		t.Fatalf("Expected the indicator to be skipped but got %v : %v", result, err)
+	}
+
+	// the policy of the service applies to the indicators without policy
+	err = SetNoDataPolicy(NoDataDefault, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = noDataResult("availability", SLIDefinition{}, noResult)
+	if err != nil || result.Value != 1 {
+		t.Fatalf("Expected the default value of the service but got %+v : %v", result, err)
+	}
+	result, err = noDataResult("availability", SLIDefinition{NoData: NoDataDefault, DefaultValue: &defaultValue}, noResult)
+	if err != nil || result.Value != defaultValue {
+		t.Fatalf("Expected the default value of the indicator but got %+v : %v", result, err)
+	}
+
+	if SetNoDataPolicy("ignore", 0) == nil {
+		t.Fatal("Expected an error for an unknown policy")
+	}
+	if _, err = resolveSLIDefinition(SLIDefinition{Query: "index=main | stats count", NoData: "ignore"}); err == nil {
+		t.Fatal("Expected an error for an indicator with an unknown policy")
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	splunkjobs "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/jobs"
+	splunkTools "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	keptnevents "github.com/keptn/go-utils/pkg/lib"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"gopkg.in/yaml.v2"
+)
+
+// PreviewOptions are the local sli.yaml and slo.yaml files previewed and the context of the evaluation
+type PreviewOptions struct {
+	SLIFile string
+	// the alerts are previewed if set
+	SLOFile string
+	Project string
+	Stage   string
+	Service string
+	// time range of the evaluation, ISO-8601 times like the ones of the get-sli events
+	Start string
+	End   string
+	// run the searches of the SLIs in splunk
+	Execute bool
+}
+
+// Preview writes the searches of the SLIs and the alerts the service would create for the given files
+//
+//	the client is used to run the SLIs, to read the saved searches and to parse the queries, it can be nil otherwise
+func Preview(w io.Writer, options PreviewOptions, envConfig utils.EnvConfig, client *splunk.SplunkClient) error {
+
+	content, err := os.ReadFile(options.SLIFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the SLI file: %w", err)
+	}
+	var file sliFile
+	err = yaml.Unmarshal(content, &file)
+	if err != nil {
+		return fmt.Errorf("invalid SLI file %s: %w", options.SLIFile, err)
+	}
+	definitions := map[string]SLIDefinition{}
+	for name, definition := range file.Indicators {
+		definitions[name], err = resolveSLIDefinition(definition)
+		if err != nil {
+			return fmt.Errorf("invalid indicator %s: %w", name, err)
+		}
+	}
+
+	var slos *keptnevents.ServiceLevelObjectives
+	if options.SLOFile != "" {
+		content, err = os.ReadFile(options.SLOFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the SLO file: %w", err)
+		}
+		slos = &keptnevents.ServiceLevelObjectives{}
+		err = yaml.Unmarshal(content, slos)
+		if err != nil {
+			return fmt.Errorf("invalid SLO file %s: %w", options.SLOFile, err)
+		}
+	}
+
+	// the evaluation requests the SLIs of the objectives
+	var indicators []string
+	if slos != nil {
+		for _, objective := range slos.Objectives {
+			indicators = append(indicators, objective.SLI)
+		}
+	} else {
+		for name := range definitions {
+			indicators = append(indicators, name)
+		}
+		sort.Strings(indicators)
+	}
+
+	_, _ = fmt.Fprintf(w, "SLIs from %s to %s\n", options.Start, options.End)
+	for _, indicatorName := range indicators {
+		previewSLI(w, indicatorName, definitions, options, client)
+	}
+
+	if slos == nil {
+		return nil
+	}
+	_, _ = fmt.Fprintf(w, "\nAlerts of the stage %s\n", options.Stage)
+	eventData := keptnv2.ConfigureMonitoringTriggeredEventData{
+		EventData: keptnv2.EventData{Project: options.Project, Stage: options.Stage, Service: options.Service},
+	}
+	for _, objective := range slos.Objectives {
+		previewObjectiveAlerts(w, eventData, objective, definitions[objective.SLI], envConfig, client)
+	}
+	return nil
+}
+
+// write the search of an indicator, with its value if it is executed
+func previewSLI(w io.Writer, indicatorName string, definitions map[string]SLIDefinition, options PreviewOptions, client *splunk.SplunkClient) {
+	_, _ = fmt.Fprintf(w, "\n%s\n", indicatorName)
+
+	search, err := buildSLISearch(indicatorName, definitions[indicatorName], options.Start, options.End)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "  error: %v\n", err)
+		return
+	}
+	if search.SavedSearch != "" {
+		_, _ = fmt.Fprintf(w, "  saved search: %s\n", search.SavedSearch)
+	} else {
+		_, _ = fmt.Fprintf(w, "  search: %s\n", splunkTools.ValidateSearchQuery(search.Params.SearchQuery))
+	}
+	_, _ = fmt.Fprintf(w, "  earliest: %s\n  latest: %s\n", search.Params.EarliestTime, search.Params.LatestTime)
+
+	if !options.Execute || client == nil {
+		return
+	}
+	data := &keptnv2.GetSLITriggeredEventData{}
+	data.GetSLI.Start = options.Start
+	data.GetSLI.End = options.End
+	result, err := handleSpecificSLI(client, indicatorName, data, definitions)
+	switch {
+	case err != nil:
+		_, _ = fmt.Fprintf(w, "  error: %v\n", err)
+	case result == nil:
+		_, _ = fmt.Fprintln(w, "  value: skipped, no data")
+	case result.Message != "":
+		_, _ = fmt.Fprintf(w, "  value: %v (%s)\n", result.Value, result.Message)
+	default:
+		_, _ = fmt.Fprintf(w, "  value: %v\n", result.Value)
+	}
+}
+
+// write the alerts of an objective as they would be created in splunk
+func previewObjectiveAlerts(w io.Writer, eventData keptnv2.ConfigureMonitoringTriggeredEventData, objective *keptnevents.SLO, definition SLIDefinition, envConfig utils.EnvConfig, client *splunk.SplunkClient) {
+	_, _ = fmt.Fprintf(w, "\n%s\n", objective.SLI)
+
+	if definition.SavedSearch != "" {
+		if client == nil {
+			_, _ = fmt.Fprintf(w, "  the alerts run the query of the saved search %s, read from splunk\n", definition.SavedSearch)
+			return
+		}
+		savedSearch, err := splunkjobs.GetSavedSearch(client, definition.SavedSearch)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "  error: %v\n", err)
+			return
+		}
+		definition.Query = savedSearch.Search
+	}
+	if definition.Query == "" {
+		_, _ = fmt.Fprintln(w, "  no query, no alert")
+		return
+	}
+
+	var parsed *spl.ParsedQuery
+	if client != nil {
+		var err error
+		parsed, err = preflightQuery(client, definition.Query)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "  error: %v\n", err)
+			return
+		}
+	}
+	resultField, err := getResultFieldName(definition, parsed)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "  error: %v\n", err)
+		return
+	}
+
+	alertParams := buildObjectiveAlerts(eventData, eventData.Stage, objective, definition.Query, resultField, envConfig)
+	if len(alertParams) == 0 {
+		_, _ = fmt.Fprintln(w, "  no absolute pass criteria, no alert")
+	}
+	for _, params := range alertParams {
+		_, _ = fmt.Fprintf(w, "  alert: %s\n", params.Name)
+		_, _ = fmt.Fprintf(w, "    search: %s\n", splunkTools.ValidateAlertQuery(params.SearchQuery))
+		_, _ = fmt.Fprintf(w, "    condition: %s\n", params.AlertCondition)
+		_, _ = fmt.Fprintf(w, "    cron: %s\n", params.CronSchedule)
+		_, _ = fmt.Fprintf(w, "    earliest: %s\n    latest: %s\n", params.EarliestTime, params.LatestTime)
+		_, _ = fmt.Fprintf(w, "    suppress period: %s\n", params.AlertSuppressPeriod)
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+)
+
+// Tests that the searches of the SLIs and the alerts of the objectives are previewed without splunk
+func TestPreview(t *testing.T) {
+	options := PreviewOptions{
+		SLIFile: "../test/data/podtatohead.sli.yaml",
+		SLOFile: "../test/data/podtatohead.slo.yaml",
+		Project: "podtatohead",
+		Stage:   "hardening",
+		Service: "helloservice",
+		Start:   "2022-07-05T12:26:40.000Z",
+		End:     "2022-07-05T12:31:40.000Z",
+	}
+	envConfig := utils.EnvConfig{CronSchedule: "3m", DispatchEarliestTime: "-3m", DispatchLatestTime: "now"}
+
+	var output bytes.Buffer
+	err := Preview(&output, options, envConfig, nil)
+	if err != nil {
+		t.Fatalf("Error while previewing the files : %v", err)
+	}
+
+	expected := []string{
+		"search: search ",
+		"earliest: 1657024000",
+		"latest: 1657024300",
+		"alert: podtatohead,hardening,helloservice,number_of_errors,>=100,keptn",
+		"condition: search count >=100",
+		"earliest: -3m",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output.String(), line) {
+			t.Errorf("Expected %q in the preview :\n%s", line, output.String())
+		}
+	}
+
+	options.SLIFile = "../test/data/missing.sli.yaml"
+	err = Preview(&output, options, envConfig, nil)
+	if err == nil {
+		t.Fatal("Expected an error for a missing SLI file")
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
+
+	"github.com/keptn/go-utils/pkg/api/models"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"gopkg.in/yaml.v2"
+)
+
+// SLIDefinition is an indicator of the splunk/sli.yaml resource,
+// either the query itself or a map with the query and its options, e.g.
+//
+//	indicators:
+//	  number_of_errors: index=main "[error]" | stats count
+//	  error_rate:
+//	    query: index=main | stats count(eval(status>=500)) AS errors, count AS total | eval rate=errors/total
+//	    resultField: rate
+//	    offset: 2m
+//	    noData: zero
+//	  checkout_error_rate:
+//	    savedsearch: Checkout Error Rate
+//	  cpu_usage:
+//	    metric:
+//	      name: cpu.usage
+//	      index: metrics
+//	      aggregation: p95
+//	      dimensions:
+//	        service: carts
+type SLIDefinition struct {
+	Query string `yaml:"query"`
+	// field of the results holding the value of the indicator, read from the query if empty
+	ResultField string `yaml:"resultField"`
+	// the time range of the evaluation is moved back by the offset for the indicator, to wait for the indexing of its events
+	Offset time.Duration `yaml:"offset"`
+	// name of a saved search (report) of splunk dispatched instead of the query
+	SavedSearch string `yaml:"savedsearch"`
+	// metric of the metrics indexes aggregated instead of the query
+	Metric *MetricDefinition `yaml:"metric"`
+	// what is done when the search returns no result (error, zero, default or skip), the policy of the service if empty
+	NoData string `yaml:"noData"`
+	// value of the indicator for the default no-data policy, the default value of the service if not set
+	DefaultValue *float64 `yaml:"defaultValue"`
+}
+
+// MetricDefinition is a metric of the metrics indexes aggregated by an indicator, see spl.MetricQuery
+type MetricDefinition struct {
+	Name            string            `yaml:"name"`
+	Index           string            `yaml:"index"`
+	Aggregation     string            `yaml:"aggregation"`
+	Dimensions      map[string]string `yaml:"dimensions"`
+	Span            string            `yaml:"span"`
+	SpanAggregation string            `yaml:"spanAggregation"`
+}
+
+// content of the splunk/sli.yaml resource
+type sliFile struct {
+	Indicators map[string]SLIDefinition `yaml:"indicators"`
+}
+
+// an indicator can be given by its query only
+func (d *SLIDefinition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var query string
+	if unmarshal(&query) == nil {
+		*d = SLIDefinition{Query: query}
+		return nil
+	}
+
+	type definition SLIDefinition
+	return unmarshal((*definition)(d))
+}
+
+// Returns the indicators of the sli.yaml files of the project, overridden by the ones of the stage and then of the service
+func getSLIDefinitions(k *keptnv2.Keptn, project string, stage string, service string) (map[string]SLIDefinition, error) {
+
+	getResources := []func() (*models.Resource, error){}
+	if project != "" {
+		getResources = append(getResources, func() (*models.Resource, error) {
+			return k.ResourceHandler.GetProjectResource(project, sliFileUri)
+		})
+	}
+	if project != "" && stage != "" {
+		getResources = append(getResources, func() (*models.Resource, error) {
+			return k.ResourceHandler.GetStageResource(project, stage, sliFileUri)
+		})
+	}
+	if project != "" && stage != "" && service != "" {
+		getResources = append(getResources, func() (*models.Resource, error) {
+			return k.ResourceHandler.GetServiceResource(project, stage, service, sliFileUri)
+		})
+	}
+
+	definitions := map[string]SLIDefinition{}
+	for _, getResource := range getResources {
+		resource, err := getResource()
+		if err != nil {
+			if strings.Contains(strings.ToLower(err.Error()), "resource not found") {
+				continue
+			}
+			return nil, err
+		}
+		if resource == nil {
+			continue
+		}
+
+		var file sliFile
+		err = yaml.Unmarshal([]byte(resource.ResourceContent), &file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", sliFileUri, err)
+		}
+		for name, definition := range file.Indicators {
+			definition, err = resolveSLIDefinition(definition)
+			if err != nil {
+				return nil, fmt.Errorf("invalid indicator %s of %s: %w", name, sliFileUri, err)
+			}
+			definitions[name] = definition
+		}
+		if len(definitions) == 0 {
+			return nil, fmt.Errorf("missing required field: indicators")
+		}
+	}
+
+	return definitions, nil
+}
+
+// checks that the indicator has one source and a known no-data policy and translates its metric into an mstats query
+func resolveSLIDefinition(definition SLIDefinition) (SLIDefinition, error) {
+	sources := 0
+	for _, isSet := range []bool{definition.Query != "", definition.SavedSearch != "", definition.Metric != nil} {
+		if isSet {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return definition, fmt.Errorf("only one of query, savedsearch and metric can be set")
+	}
+	if definition.NoData != "" {
+		err := checkNoDataPolicy(definition.NoData)
+		if err != nil {
+			return definition, err
+		}
+	}
+
+	if definition.Metric != nil {
+		query, err := spl.MetricQuery(*definition.Metric).Query()
+		if err != nil {
+			return definition, err
+		}
+		definition.Query = query.String()
+		if definition.ResultField == "" {
+			definition.ResultField = spl.MetricValueField
+		}
+	}
+	return definition, nil
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
+	"gopkg.in/yaml.v2"
+)
+
+// Tests that the indicators are read either as a query or as a map with the query and its options
+func TestSLIDefinitionUnmarshal(t *testing.T) {
+	content := `indicators:
+  number_of_errors: index=main "[error]" | stats count
+  error_rate:
+    query: index=main | stats count(eval(status>=500)) AS errors, count AS total | eval rate=errors/total
+    resultField: rate
+    offset: 2m
+  checkout_error_rate:
+    savedsearch: Checkout Error Rate
+`
+	var file sliFile
+	err := yaml.Unmarshal([]byte(content), &file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if file.Indicators["number_of_errors"] != (SLIDefinition{Query: `index=main "[error]" | stats count`}) {
+		t.Fatalf("Unexpected definition %+v", file.Indicators["number_of_errors"])
+	}
+	if file.Indicators["error_rate"].ResultField != "rate" || file.Indicators["error_rate"].Offset != 2*time.Minute || !strings.HasPrefix(file.Indicators["error_rate"].Query, "index=main") {
+		t.Fatalf("Unexpected definition %+v", file.Indicators["error_rate"])
+	}
+	if file.Indicators["checkout_error_rate"] != (SLIDefinition{SavedSearch: "Checkout Error Rate"}) {
+		t.Fatalf("Unexpected definition %+v", file.Indicators["checkout_error_rate"])
+	}
+}
+
+// Tests the priority between the result field of the config, the parsed query and the query itself
+func TestGetResultFieldName(t *testing.T) {
+	query := "index=main | stats count AS total"
+
+	resultField, err := getResultFieldName(SLIDefinition{Query: query, ResultField: "other"}, nil)
+	if err != nil || resultField != "other" {
+		t.Fatalf("Expected the result field of the config but got %s : %v", resultField, err)
+	}
+
+	parsed := &spl.ParsedQuery{Commands: []spl.Command{
+		{Command: "search", RawArgs: "index=main"},
+		{Command: "stats", RawArgs: "avg(duration) AS latency"},
+	}}
+	resultField, err = getResultFieldName(SLIDefinition{Query: query}, parsed)
+	if err != nil || resultField != "latency" {
+		t.Fatalf("Expected the result field of the parsed query but got %s : %v", resultField, err)
+	}
+
+	resultField, err = getResultFieldName(SLIDefinition{Query: query}, nil)
+	if err != nil || resultField != "total" {
+		t.Fatalf("Expected the result field of the query but got %s : %v", resultField, err)
+	}
+
+	_, err = getResultFieldName(SLIDefinition{Query: "index=main | stats count, avg(duration)"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "resultField") {
+		t.Fatalf("Expected an error hinting at the resultField but got %v", err)
+	}
+}
+
+// Tests that the metric of an indicator is translated into an mstats query and that an indicator has one source
+func TestResolveSLIDefinition(t *testing.T) {
+	definition, err := resolveSLIDefinition(SLIDefinition{Metric: &MetricDefinition{Name: "cpu.usage", Index: "metrics", Aggregation: "max", Dimensions: map[string]string{"service": "carts"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if definition.Query != `| mstats max(_value) AS value WHERE index="metrics" metric_name="cpu.usage" service="carts"` || definition.ResultField != "value" {
+		t.Fatalf("Unexpected definition %+v", definition)
+	}
+
+	_, err = resolveSLIDefinition(SLIDefinition{Metric: &MetricDefinition{Name: "cpu.usage", Aggregation: "average"}})
+	if err == nil {
+		t.Fatal("Expected an error for an unknown aggregation")
+	}
+
+	_, err = resolveSLIDefinition(SLIDefinition{Query: "index=main | stats count", SavedSearch: "Checkout Error Rate"})
+	if err == nil {
+		t.Fatal("Expected an error for an indicator with a query and a saved search")
+	}
+}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ECL2022PAI01/splunk-service/alerts"
 	"github.com/ECL2022PAI01/splunk-service/handler"
 	splunkalerts "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/alerts"
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/kvstore"
 	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2" // make sure to use v2 cloudevents here
@@ -30,7 +34,9 @@ const (
 var env utils.EnvConfig
 var keptnOptions keptn.KeptnOpts
 var splunkClient *splunk.SplunkClient
-var pollingSystemHasBeenStarted bool
+
+// sends the keptn events to the HTTP Event Collector of splunk, nil if forwarding is disabled
+var eventForwarder *hec.Batcher
 
 // based on https://github.com/sirupsen/logrus/pull/653#issuecomment-454467900
 
@@ -144,7 +150,7 @@ func ProcessKeptnCloudEvent(ctx context.Context, event cloudevents.Event) error
 		eventDatav2.ConfigureMonitoring.Type = eventDatav1.Type
 		event.SetType(keptnv2.GetTriggeredEventType(keptnv2.ConfigureMonitoringTaskName))
 
-		return handleConfigureMonitoringTriggeredEvent(ddKeptn, event, eventDatav2, env, splunkClient, pollingSystemHasBeenStarted)
+		return handleConfigureMonitoringTriggeredEvent(ddKeptn, event, eventDatav2, env, splunkClient)
 
 	// -------------------------------------------------------
 	// sh.keptn.event.get-sli (sent by lighthouse-service to fetch SLIs from the sli provider)
@@ -159,9 +165,38 @@ func ProcessKeptnCloudEvent(ctx context.Context, event cloudevents.Event) error
 
 		return handleGetSliTriggeredEvent(ddKeptn, event, eventData, splunkClient)
 
+	// -------------------------------------------------------
+	// sh.keptn.event.deployment.finished and sh.keptn.event.release.finished (annotated in splunk)
+	case keptnv2.GetFinishedEventType(keptnv2.DeploymentTaskName), keptnv2.GetFinishedEventType(keptnv2.ReleaseTaskName):
+		if eventForwarder != nil {
+			var errs []error
+			if env.SplunkHECAnnotations {
+				logger.Infof("Processing %s Event", eType)
+				errs = append(errs, handleDeploymentAnnotationEvent(eventForwarder, event, env))
+			}
+			forwarded, err := forwardToSplunk(event)
+
+			// the event is unhandled like in the default case if it is neither annotated nor forwarded
+			if env.SplunkHECAnnotations || forwarded {
+				return errors.Join(append(errs, err)...)
+			}
+		}
+
+		err = fmt.Errorf("%s %s", UnhandleKeptnCloudEvent, eType)
+		logger.Errorf("got error while processing cloud event : %v", err)
+		return err
+
 	// -------------------------------------------------------
 	// Unknown Event -> Throw Error!
 	default:
+		// forward the selected keptn events and the evaluation results to the HTTP Event Collector of splunk
+		if eventForwarder != nil {
+			forwarded, err := forwardToSplunk(event)
+			if forwarded {
+				return err
+			}
+		}
+
 		err = fmt.Errorf("%s %s", UnhandleKeptnCloudEvent, eType)
 		logger.Errorf("got error while processing cloud event : %v", err)
 		return err
@@ -170,6 +205,30 @@ func ProcessKeptnCloudEvent(ctx context.Context, event cloudevents.Event) error
 
 }
 
+// Sends the event to the HTTP Event Collector if it is forwarded or if it is an evaluation whose results are sent as metrics
+func forwardToSplunk(event cloudevents.Event) (bool, error) {
+	var errs []error
+	forwarded := false
+
+	if handler.IsForwardedEvent(event.Type(), env.SplunkHECEvents) {
+		forwarded = true
+		errs = append(errs, handleForwardedEvent(eventForwarder, event, env))
+	}
+
+	if env.SplunkHECEvaluationMetrics && event.Type() == keptnv2.GetFinishedEventType(keptnv2.EvaluationTaskName) {
+		forwarded = true
+		eventData := &keptnv2.EvaluationFinishedEventData{}
+		err := parseKeptnCloudEventPayload(event, eventData)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Enable to parse keptn cloud event payload %w", err))
+		} else {
+			errs = append(errs, handleEvaluationFinishedEvent(eventForwarder, event, eventData, env))
+		}
+	}
+
+	return forwarded, errors.Join(errs...)
+}
+
 /**
  * Usage: ./main
  * no args: starts listening for cloudnative events on localhost:port/path
@@ -181,6 +240,9 @@ func ProcessKeptnCloudEvent(ctx context.Context, event cloudevents.Event) error
 var processKeptnCloudEvent = ProcessKeptnCloudEvent
 var handleConfigureMonitoringTriggeredEvent = handler.HandleConfigureMonitoringTriggeredEvent
 var handleGetSliTriggeredEvent = handler.HandleGetSliTriggeredEvent
+var handleForwardedEvent = handler.HandleForwardedEvent
+var handleEvaluationFinishedEvent = handler.HandleEvaluationFinishedEvent
+var handleDeploymentAnnotationEvent = handler.HandleDeploymentAnnotationEvent
 
 func main() {
 	utils.ConfigureLogger("", "", "")
@@ -190,17 +252,127 @@ func main() {
 		logger.Fatalf("Failed to process env var: %s", err)
 	}
 
+	// the subcommands of the binary
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "preview":
+			err = runPreview(os.Args[2:], os.Stdout)
+			if err != nil {
+				logger.Fatalf("Preview failed: %s", err)
+			}
+			return
+		case "alerts":
+			err = runAlerts(os.Args[2:], os.Stdout)
+			if err != nil {
+				logger.Fatalf("Alerts command failed: %s", err)
+			}
+			return
+		}
+	}
+
+	if env.MetricsPort != 0 {
+		go utils.StartMetricsServer(env.MetricsPort)
+	}
+
+	// forward the keptn events to the HTTP Event Collector of splunk
+	if env.SplunkHECURL != "" {
+		eventForwarder, err = utils.NewHECBatcher(env)
+		if err != nil {
+			logger.Fatalf("Failed to configure the splunk HTTP Event Collector: %s", err)
+		}
+		go eventForwarder.Run(context.Background())
+		logger.Infof("Forwarding the finished events of %s to the splunk HTTP Event Collector", env.SplunkHECEvents)
+		if env.SplunkHECEvaluationMetrics {
+			logger.Infof("Sending the results of the evaluations to the splunk metrics index %s", env.SplunkHECMetricsIndex)
+		}
+	} else if env.SplunkHECEvaluationMetrics {
+		logger.Warn("SP_HEC_EVALUATION_METRICS is set without SP_HEC_URL, the results of the evaluations are not sent")
+	}
+
 	// create splunk credentials
 	splunkCreds, err := utils.GetSplunkCredentials(env)
 
-	if err != nil {
+	switch {
+	case err == nil:
+		// connect to splunk
+		splunkClient, err = utils.NewSplunkClient(*splunkCreds, env)
+		if err != nil {
+			logger.Fatalf("Failed to connect to splunk: %s", err)
+		}
+
+		// reload the credentials when the mounted secret is rotated
+		if env.SplunkCredentialsPath != "" {
+			go utils.WatchSplunkCredentials(context.Background(), env, *splunkCreds, splunkClient)
+		}
+	case env.SplunkConnectionsFile == "":
 		logger.Fatalf("Failed to get splunk credentials: %s", err)
+	default:
+		logger.Infof("No splunk credentials in the environment, using the default connection of %s", env.SplunkConnectionsFile)
+	}
+
+	// load the additional splunk instances
+	splunkConnections := utils.NewSplunkConnections(splunkClient)
+	if env.SplunkConnectionsFile != "" {
+		splunkConnections, err = utils.LoadSplunkConnections(env.SplunkConnectionsFile, env, splunkClient)
+		if err != nil {
+			logger.Fatalf("Failed to load splunk connections: %s", err)
+		}
+		handler.SetSplunkConnections(splunkConnections)
+	}
+	splunkClient = splunkConnections.Default()
+	if splunkClient == nil {
+		logger.Fatalf("No default splunk connection defined in %s", env.SplunkConnectionsFile)
+	}
+
+	// read the credentials brought by the projects in kubernetes secrets
+	if env.SplunkProjectCredentials {
+		projectCredentials, err := utils.NewInClusterProjectCredentials(env)
+		if err != nil {
+			logger.Fatalf("Failed to enable the project credentials: %s", err)
+		}
+		handler.SetProjectCredentials(projectCredentials)
+	}
+
+	// remember the forwarded alerts in the kv store of splunk so that restarts and replicas do not forward them again
+	if env.SplunkKVStoreApp != "" {
+		namespace := kvstore.Namespace{Owner: env.SplunkKVStoreOwner, App: env.SplunkKVStoreApp}
+		stateStore, err := alerts.NewKVStateStore(splunkClient, namespace, env.SplunkKVStoreAlertsCollection)
+		if err != nil {
+			logger.Fatalf("Failed to configure the splunk KV Store: %s", err)
+		}
+		alerts.SetAlertStateStore(stateStore)
+		logger.Infof("Saving the state of the triggered alerts in the KV Store collection %s of the app %s", env.SplunkKVStoreAlertsCollection, env.SplunkKVStoreApp)
 	}
-	// connect to splunk
-	splunkClient = utils.ConnectToSplunk(*splunkCreds, true)
 
-	// start polling if alerts are configured
-	alertsList, err := splunkalerts.ListAlertsNames(splunkClient)
+	configureSLIs()
+
+	// start polling the splunk instances on which alerts are configured
+	for _, client := range splunkConnections.Clients() {
+		startPollingIfAlertsConfigured(client)
+	}
+
+	CloudEventListener(os.Args[1:])
+}
+
+// Configures how the SLIs are searched from the environment
+func configureSLIs() {
+	handler.SetSLISearchTimeout(env.SplunkSLISearchTimeout)
+	handler.SetSLICacheTTL(env.SplunkSLICacheTTL)
+	err := handler.SetNoDataPolicy(env.SplunkSLINoData, env.SplunkSLINoDataDefaultValue)
+	if err != nil {
+		logger.Fatalf("Invalid SP_SLI_NO_DATA: %s", err)
+	}
+	location, err := time.LoadLocation(env.SplunkTimezone)
+	if err != nil {
+		logger.Fatalf("Invalid time zone %s: %s", env.SplunkTimezone, err)
+	}
+	handler.SetTimeLocation(location)
+}
+
+// Starts polling the triggered alerts of the splunk instance if keptn alerts are configured on it
+func startPollingIfAlertsConfigured(client *splunk.SplunkClient) {
+
+	alertsList, err := splunkalerts.ListAlertsNames(client)
 	if err != nil {
 		logger.Fatalf("Failed to get alerts list: %s", err)
 	}
@@ -211,16 +383,10 @@ func main() {
 			continue
 		}
 
-		go func() {
-			logger.Info("Start polling for triggered alerts ...")
-			alerts.FiringAlertsPoll(splunkClient, nil, keptnOptions, env)
-		}()
-		pollingSystemHasBeenStarted = true
+		logger.Info("Start polling for triggered alerts ...")
+		alerts.StartFiringAlertsPoll(client, nil, keptnOptions, env)
 		break
-
 	}
-
-	CloudEventListener(os.Args[1:])
 }
 
 /**
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
 	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -51,7 +52,7 @@ func TestProcessKeptnCloudEvent(t *testing.T) {
 	*calledSLI = false
 	*calledConfig = false
 
-	handleConfigureMonitoringTriggeredEvent = func(ddKeptn *keptnv2.Keptn, incomingEvent event.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, env utils.EnvConfig, client *splunk.SplunkClient, pollingSystemHasBeenStarted bool) error {
+	handleConfigureMonitoringTriggeredEvent = func(ddKeptn *keptnv2.Keptn, incomingEvent event.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, env utils.EnvConfig, client *splunk.SplunkClient) error {
 		*calledConfig = true
 		return nil
 	}
@@ -70,6 +71,81 @@ func TestProcessKeptnCloudEvent(t *testing.T) {
 	checkProcessKeptnCloudEvent(t, "test/events/release.triggered.json", calledSLI, calledConfig)
 }
 
+// Tests that the finished events are forwarded to the HTTP Event Collector when it is configured
+func TestProcessKeptnCloudEventForwarding(t *testing.T) {
+
+	var forwarded bool
+	handleForwardedEvent = func(batcher *hec.Batcher, incomingEvent event.Event, env utils.EnvConfig) error {
+		forwarded = true
+		return nil
+	}
+	env.SplunkHECEvents = "deployment,evaluation,remediation"
+	eventForwarder = hec.NewBatcher(nil, 1, 0)
+	defer func() { eventForwarder = nil }()
+
+	incomingEvent, err := extractEvent("test/events/evaluation.finished.json")
+	if err != nil {
+		t.Fatalf("Error getting keptn event : %v", err)
+	}
+	err = processKeptnCloudEvent(context.Background(), *incomingEvent)
+	if err != nil || !forwarded {
+		t.Fatalf("The evaluation.finished event has not been forwarded : %v", err)
+	}
+
+	// the results of the evaluation are also sent as metrics if enabled
+	var sentAsMetrics bool
+	handleEvaluationFinishedEvent = func(batcher *hec.Batcher, incomingEvent event.Event, data *keptnv2.EvaluationFinishedEventData, env utils.EnvConfig) error {
+		sentAsMetrics = data.Evaluation.Score == 100
+		return nil
+	}
+	env.SplunkHECEvaluationMetrics = true
+	defer func() { env.SplunkHECEvaluationMetrics = false }()
+
+	forwarded = false
+	err = processKeptnCloudEvent(context.Background(), *incomingEvent)
+	if err != nil || !forwarded || !sentAsMetrics {
+		t.Fatalf("The evaluation.finished event has not been forwarded and sent as metrics : %v", err)
+	}
+}
+
+// Tests that the deployment.finished events are annotated in splunk when annotations are enabled
+func TestProcessKeptnCloudEventAnnotation(t *testing.T) {
+
+	var annotated bool
+	handleDeploymentAnnotationEvent = func(batcher *hec.Batcher, incomingEvent event.Event, env utils.EnvConfig) error {
+		annotated = true
+		return nil
+	}
+	handleForwardedEvent = func(batcher *hec.Batcher, incomingEvent event.Event, env utils.EnvConfig) error {
+		return nil
+	}
+	env.SplunkHECAnnotations = true
+	eventForwarder = hec.NewBatcher(nil, 1, 0)
+	defer func() {
+		eventForwarder = nil
+		env.SplunkHECAnnotations = false
+	}()
+
+	incomingEvent, err := extractEvent("test/events/deployment.finished.json")
+	if err != nil {
+		t.Fatalf("Error getting keptn event : %v", err)
+	}
+	err = processKeptnCloudEvent(context.Background(), *incomingEvent)
+	if err != nil || !annotated {
+		t.Fatalf("The deployment.finished event has not been annotated : %v", err)
+	}
+
+	// neither annotated nor forwarded, the event is unhandled
+	savedEvents := env.SplunkHECEvents
+	defer func() { env.SplunkHECEvents = savedEvents }()
+	env.SplunkHECAnnotations = false
+	env.SplunkHECEvents = "evaluation"
+	err = processKeptnCloudEvent(context.Background(), *incomingEvent)
+	if err == nil || !strings.Contains(err.Error(), UnhandleKeptnCloudEvent) {
+		t.Fatalf("Expected the deployment.finished event to be unhandled but got %v", err)
+	}
+}
+
 // Tests the _main function by ensuring that it listens to cloudevents and trigger the procKeptnCE function
 func TestCloudEventListener(t *testing.T) {
 
@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http/httputil"
-	"strconv"
 	"strings"
 
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
@@ -84,38 +82,25 @@ type Content struct {
 func CreateAlert(client *splunk.SplunkClient, spAlert *AlertRequest) error {
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, savedSearchesPath)
+	endpoint := client.BuildEndpoint(savedSearchesPath)
 	spAlert.Params.SearchQuery = utils.ValidateAlertQuery(spAlert.Params.SearchQuery)
 
-	resp, err := PostAlert(client, spAlert)
-
-	var respDump []byte
-	var errDump error
-	if resp != nil {
-		respDump, errDump = httputil.DumpResponse(resp, true)
-		if errDump != nil {
-			fmt.Println(errDump)
-		}
-	}
+	resp, err := PostAlert(client, endpoint, spAlert)
 
 	if err != nil {
-		return fmt.Errorf("alert creation : error while making the post request : %s", err)
+		return fmt.Errorf("alert creation : error while making the post request : %w", err)
 	}
 
+	defer resp.Body.Close()
+
 	body, err := io.ReadAll(resp.Body)
-	// handle error
-	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return fmt.Errorf("alert creation : http error :  %s \nResponse : %v", status, string(respDump))
-		default:
-			return fmt.Errorf("alert creation : http error :  %s \nResponse : %v", resp.Status, string(respDump))
-		}
+	if err != nil {
+		return fmt.Errorf("alert creation : error while getting the body of the post request : %w", err)
 	}
-
+	// handle error
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
 	if err != nil {
-		return fmt.Errorf("alert creation : error while getting the body of the post request : %s", err)
+		return fmt.Errorf("alert creation : http error : %w", err)
 	}
 
 	return nil
@@ -125,40 +110,27 @@ func CreateAlert(client *splunk.SplunkClient, spAlert *AlertRequest) error {
 func RemoveAlert(client *splunk.SplunkClient, alertName string) error {
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, savedSearchesPath+alertName)
+	endpoint := client.BuildEndpoint(savedSearchesPath + alertName)
 
 	splunkAlert := AlertRequest{}
 	splunkAlert.Params.Name = alertName
 
-	resp, err := DeleteAlert(client, &splunkAlert)
-
-	var respDump []byte
-	var errDump error
-	if resp != nil {
-		respDump, errDump = httputil.DumpResponse(resp, true)
-		if errDump != nil {
-			fmt.Println(errDump)
-		}
-	}
+	resp, err := DeleteAlert(client, endpoint, &splunkAlert)
 
 	if err != nil {
-		return fmt.Errorf("alert Removing : error while making the delete request : %s", err)
+		return fmt.Errorf("alert Removing : error while making the delete request : %w", err)
 	}
 
+	defer resp.Body.Close()
+
 	body, err := io.ReadAll(resp.Body)
-	// handle error
-	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return fmt.Errorf("alert Removing : http error :  %s \nResponse : %v", status, string(respDump))
-		default:
-			return fmt.Errorf("alert Removing : http error :  %s \nResponse : %v", resp.Status, string(respDump))
-		}
+	if err != nil {
+		return fmt.Errorf("alert Removing : error while getting the body of the delete request : %w", err)
 	}
-
+	// handle error
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
 	if err != nil {
-		return fmt.Errorf("alert Removing : error while getting the body of the delete request : %s", err)
+		return fmt.Errorf("alert Removing : http error : %w", err)
 	}
 
 	return nil
@@ -170,37 +142,24 @@ func ListAlertsNames(client *splunk.SplunkClient) (splunkAlertList, error) {
 	var alertList splunkAlertList
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, savedSearchesPath)
+	endpoint := client.BuildEndpoint(savedSearchesPath)
 
-	resp, err := GetAlerts(client)
-
-	var respDump []byte
-	var errDump error
-	if resp != nil {
-		respDump, errDump = httputil.DumpResponse(resp, true)
-		if errDump != nil {
-			fmt.Println(errDump)
-		}
-	}
+	resp, err := GetAlerts(client, endpoint)
 
 	if err != nil {
-		return alertList, fmt.Errorf("alerts' names listing : error while making the get request : %s", err)
+		return alertList, fmt.Errorf("alerts' names listing : error while making the get request : %w", err)
 	}
 
+	defer resp.Body.Close()
+
 	body, err := io.ReadAll(resp.Body)
-	// handle error
-	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return alertList, fmt.Errorf("alerts' names listing : http error :  %s \nResponse : %v", status, string(respDump))
-		default:
-			return alertList, fmt.Errorf("alerts' names listing : http error :  %s \nResponse : %v", resp.Status, string(respDump))
-		}
+	if err != nil {
+		return alertList, fmt.Errorf("alerts' names listing : error while getting the body of the get request : %w", err)
 	}
-
+	// handle error
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
 	if err != nil {
-		return alertList, fmt.Errorf("alerts' names listing : error while getting the body of the get request : %s", err)
+		return alertList, fmt.Errorf("alerts' names listing : http error : %w", err)
 	}
 
 	err = json.Unmarshal(body, &alertList)
@@ -216,37 +175,24 @@ func GetTriggeredAlerts(client *splunk.SplunkClient) (TriggeredAlerts, error) {
 	var triggeredAlerts TriggeredAlerts
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, triggeredAlertsPath)
+	endpoint := client.BuildEndpoint(triggeredAlertsPath)
 
-	resp, err := GetAlerts(client)
-
-	var respDump []byte
-	var errDump error
-	if resp != nil {
-		respDump, errDump = httputil.DumpResponse(resp, true)
-		if errDump != nil {
-			fmt.Println(errDump)
-		}
-	}
+	resp, err := GetAlerts(client, endpoint)
 
 	if err != nil {
-		return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : error while making the get request : %s", err)
+		return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : error while making the get request : %w", err)
 	}
 
+	defer resp.Body.Close()
+
 	body, err := io.ReadAll(resp.Body)
-	// handle error
-	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : http error :  %s \nResponse : %s", status, string(respDump))
-		default:
-			return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : http error :  %s \nResponse : %s", resp.Status, string(respDump))
-		}
+	if err != nil {
+		return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : error while getting the body of the get request : %w", err)
 	}
-
+	// handle error
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
 	if err != nil {
-		return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : error while getting the body of the get request : %s", err)
+		return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : http error : %w", err)
 	}
 
 	err = json.Unmarshal(body, &triggeredAlerts)
@@ -262,38 +208,25 @@ func GetInstancesOfTriggeredAlert(client *splunk.SplunkClient, link string) (Tri
 	var triggeredInstances TriggeredInstances
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, strings.TrimPrefix(link, "/"))
+	endpoint := client.BuildEndpoint(strings.TrimPrefix(link, "/"))
 
-	resp, err := GetAlerts(client)
-
-	var respDump []byte
-	var errDump error
-	if resp != nil {
-		respDump, errDump = httputil.DumpResponse(resp, true)
-		if errDump != nil {
-			fmt.Println(errDump)
-		}
-	}
+	resp, err := GetAlerts(client, endpoint)
 
 	if err != nil {
-		return triggeredInstances, fmt.Errorf("triggered instances' names listing : error while making the get request : %s", err)
+		return triggeredInstances, fmt.Errorf("triggered instances' names listing : error while making the get request : %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	// handle error
-	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return triggeredInstances, fmt.Errorf("triggered instances' names listing : http error :  %s \nResponse : %s, LINK : %s", status, string(respDump), client.Endpoint)
-		default:
-			return triggeredInstances, fmt.Errorf("triggered instances' names listing : http error :  %s \nResponse : %s, LINK : %s", status, string(respDump), client.Endpoint)
-		}
-	}
+	defer resp.Body.Close()
 
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return triggeredInstances, fmt.Errorf("triggered instances' names listing : error while getting the body of the get request : %w", err)
 	}
+	// handle error
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
+	if err != nil {
+		return triggeredInstances, fmt.Errorf("triggered instances' names listing : http error : %w", err)
+	}
 
 	err = json.Unmarshal(body, &triggeredInstances)
 	if err != nil {
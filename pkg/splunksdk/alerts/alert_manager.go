@@ -7,22 +7,22 @@ import (
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
 )
 
-func PostAlert(client *splunk.SplunkClient, spAlert *AlertRequest) (*http.Response, error) {
+func PostAlert(client *splunk.SplunkClient, endpoint string, spAlert *AlertRequest) (*http.Response, error) {
 
-	return HttpAlertRequest(client, http.MethodPost, spAlert)
+	return HttpAlertRequest(client, http.MethodPost, endpoint, spAlert)
 }
 
-func GetAlerts(client *splunk.SplunkClient) (*http.Response, error) {
+func GetAlerts(client *splunk.SplunkClient, endpoint string) (*http.Response, error) {
 
-	return HttpAlertRequest(client, http.MethodGet, nil)
+	return HttpAlertRequest(client, http.MethodGet, endpoint, nil)
 }
 
-func DeleteAlert(client *splunk.SplunkClient, spAlert *AlertRequest) (*http.Response, error) {
+func DeleteAlert(client *splunk.SplunkClient, endpoint string, spAlert *AlertRequest) (*http.Response, error) {
 
-	return HttpAlertRequest(client, "DELETE", spAlert)
+	return HttpAlertRequest(client, "DELETE", endpoint, spAlert)
 }
 
-func HttpAlertRequest(client *splunk.SplunkClient, method string, spAlert *AlertRequest) (*http.Response, error) {
+func HttpAlertRequest(client *splunk.SplunkClient, method string, endpoint string, spAlert *AlertRequest) (*http.Response, error) {
 
 	if spAlert == nil {
 		spAlert = &AlertRequest{}
@@ -79,8 +79,12 @@ func HttpAlertRequest(client *splunk.SplunkClient, method string, spAlert *Alert
 		params.Add("alert.track", "1")
 
 	}
+	if method == http.MethodGet {
+		// all the entries instead of the first 30
+		endpoint += "?count=0"
+	}
 	if spAlert.Headers == nil {
 		spAlert.Headers = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
 	}
-	return splunk.MakeHttpRequest(client, method, spAlert.Headers, params)
+	return splunk.MakeHttpRequestToEndpoint(client, method, endpoint, spAlert.Headers, params.Encode())
 }
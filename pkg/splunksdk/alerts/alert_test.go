@@ -0,0 +1,44 @@
+package alerts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	utils "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+// Tests that the search of the created alert is saved without the search keyword and with the pipe before a metric command
+func TestCreateAlertSearch(t *testing.T) {
+	var saved string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/"+savedSearchesPath {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_ = r.ParseForm()
+		saved = r.PostForm.Get("search")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"entry":[]}`))
+	}))
+	defer server.Close()
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, utils.GetTestHostname(server), utils.GetTestPort(server), utils.GetTestToken(), true)
+
+	for query, expected := range map[string]string{
+		"search index=main | stats count":                    "index=main | stats count",
+		"index=main | stats count":                           "index=main | stats count",
+		"mstats avg(cpu) WHERE index=metrics":                "| mstats avg(cpu) WHERE index=metrics",
+		"tstats count where index=main":                      "| tstats count where index=main",
+		"| mstats avg(cpu) WHERE index=metrics":              "| mstats avg(cpu) WHERE index=metrics",
+		"  MSTATS max(memory) WHERE index=metrics BY host  ": "| MSTATS max(memory) WHERE index=metrics BY host",
+	} {
+		spAlert := AlertRequest{Params: AlertParams{Name: "alert", SearchQuery: query}}
+		err := CreateAlert(client, &spAlert)
+		if err != nil {
+			t.Fatalf("Got an error : %s", err)
+		}
+		if saved != expected {
+			t.Errorf("Expected the search %q to be saved as %q but got %q", query, expected, saved)
+		}
+	}
+}
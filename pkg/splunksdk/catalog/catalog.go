@@ -0,0 +1,127 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+const metricsPath = "services/catalog/metricstore/metrics"
+const dimensionsPath = "services/catalog/metricstore/dimensions"
+
+// Filter restricts the metrics of the catalog
+type Filter struct {
+	// metrics indexes searched, all the metrics indexes if empty
+	Indexes []string
+	// time range of the metric values, the last 24 hours of splunk if empty
+	EarliestTime string
+	LatestTime   string
+}
+
+type entryList struct {
+	Entry []struct {
+		Name string `json:"name"`
+	} `json:"entry"`
+}
+
+// ListMetrics returns the names of the metrics of the metrics indexes
+func ListMetrics(client *splunk.SplunkClient, filter Filter) ([]string, error) {
+
+	body, err := doRequest(client, metricsPath, filter.params())
+	if err != nil {
+		return nil, fmt.Errorf("error while listing the metrics : %w", err)
+	}
+	return entryNames(body)
+}
+
+// ListDimensions returns the dimensions of the metric, or of all the metrics if metricName is empty
+func ListDimensions(client *splunk.SplunkClient, metricName string, filter Filter) ([]string, error) {
+
+	params := filter.params()
+	if metricName != "" {
+		params.Add("metric_name", metricName)
+	}
+	body, err := doRequest(client, dimensionsPath, params)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing the dimensions of %s : %w", metricName, err)
+	}
+	return entryNames(body)
+}
+
+// ListDimensionValues returns the values of a dimension of the metric, or of all the metrics if metricName is empty
+func ListDimensionValues(client *splunk.SplunkClient, metricName string, dimension string, filter Filter) ([]string, error) {
+
+	params := filter.params()
+	if metricName != "" {
+		params.Add("metric_name", metricName)
+	}
+	body, err := doRequest(client, dimensionsPath+"/"+url.PathEscape(dimension)+"/values", params)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing the values of the dimension %s : %w", dimension, err)
+	}
+	return entryNames(body)
+}
+
+// parameters of the requests to the catalog
+func (filter Filter) params() url.Values {
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	// all the entries
+	params.Add("count", "0")
+	for _, index := range filter.Indexes {
+		params.Add("filter", "index="+index)
+	}
+	if filter.EarliestTime != "" {
+		params.Add("earliest", filter.EarliestTime)
+	}
+	if filter.LatestTime != "" {
+		params.Add("latest", filter.LatestTime)
+	}
+	return params
+}
+
+// return the sorted names of the entries of the response
+func entryNames(body []byte) ([]string, error) {
+	var list entryList
+	err := json.Unmarshal(body, &list)
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding the catalog : %w", err)
+	}
+
+	names := make([]string, 0, len(list.Entry))
+	for _, entry := range list.Entry {
+		names = append(names, entry.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func doRequest(client *splunk.SplunkClient, path string, params url.Values) ([]byte, error) {
+
+	// create the endpoint for the request
+	endpoint := client.BuildEndpoint(path) + "?" + params.Encode()
+
+	resp, err := splunk.MakeHttpRequestToEndpoint(client, http.MethodGet, endpoint, map[string]string{}, "")
+	if err != nil {
+		return nil, fmt.Errorf("error while making the request : %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the body of the request : %w", err)
+	}
+	// handle error
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http error : %w", err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	utils "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+// Builds a fake metrics catalog with the metrics cpu.usage and http.latency of the index metrics
+func buildMockCatalog(t *testing.T) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("output_mode") != "json" || query.Get("filter") != "index=metrics" {
+			t.Errorf("Unexpected parameters %v", query)
+		}
+
+		switch r.URL.Path {
+		case "/" + metricsPath:
+			_, _ = fmt.Fprint(w, `{"entry":[{"name":"http.latency"},{"name":"cpu.usage"}]}`)
+		case "/" + dimensionsPath:
+			if query.Get("metric_name") != "http.latency" {
+				t.Errorf("Unexpected metric %s", query.Get("metric_name"))
+			}
+			_, _ = fmt.Fprint(w, `{"entry":[{"name":"host"},{"name":"endpoint"}]}`)
+		case "/" + dimensionsPath + "/host/values":
+			_, _ = fmt.Fprint(w, `{"entry":[{"name":"web-2"},{"name":"web-1"}]}`)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCatalog(t *testing.T) {
+	server := buildMockCatalog(t)
+	defer server.Close()
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, utils.GetTestHostname(server), utils.GetTestPort(server), utils.GetTestToken(), true)
+	filter := Filter{Indexes: []string{"metrics"}}
+
+	metrics, err := ListMetrics(client, filter)
+	if err != nil || !reflect.DeepEqual(metrics, []string{"cpu.usage", "http.latency"}) {
+		t.Fatalf("Unexpected metrics %v : %v", metrics, err)
+	}
+
+	dimensions, err := ListDimensions(client, "http.latency", filter)
+	if err != nil || !reflect.DeepEqual(dimensions, []string{"endpoint", "host"}) {
+		t.Fatalf("Unexpected dimensions %v : %v", dimensions, err)
+	}
+
+	values, err := ListDimensionValues(client, "", "host", filter)
+	if err != nil || !reflect.DeepEqual(values, []string{"web-1", "web-2"}) {
+		t.Fatalf("Unexpected values %v : %v", values, err)
+	}
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const loginPath = "services/auth/login"
+
+type loginResponse struct {
+	SessionKey string `json:"sessionKey"`
+}
+
+// create a new client that exchanges its username and password for a session key
+// the session key is requested on the first call and renewed when it expires
+func NewClientAuthenticatedByLogin(client *http.Client, host string, port string, username string, password string, skipSSL bool) *SplunkClient {
+	spClient := NewBasicAuthenticatedClient(client, host, port, username, password, skipSSL)
+	spClient.SessionLogin = true
+
+	return spClient
+}
+
+// Login exchanges the username and password of the client for a new session key
+func Login(client *SplunkClient) error {
+
+	client.mu.RLock()
+	username, password := client.Username, client.Password
+	client.mu.RUnlock()
+
+	if username == "" || password == "" {
+		return fmt.Errorf("login : no username and password provided")
+	}
+
+	params := url.Values{}
+	params.Add("username", username)
+	params.Add("password", password)
+	params.Add("output_mode", "json")
+
+	endpoint := client.BuildEndpoint(loginPath)
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+
+	resp, err := doWithRetry(client, http.MethodPost, endpoint, headers, params.Encode())
+	if err != nil {
+		return fmt.Errorf("login : error while making the post request : %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("login : error while getting the body of the post request : %w", err)
+	}
+	err = CheckHttpResponse(resp, body, endpoint)
+	if err != nil {
+		return fmt.Errorf("login : http error : %w", err)
+	}
+
+	var loginResp loginResponse
+	err = json.Unmarshal(body, &loginResp)
+	if err != nil {
+		return fmt.Errorf("login : could not read the session key : %w", err)
+	}
+	if loginResp.SessionKey == "" {
+		return fmt.Errorf("login : no session key returned by splunk")
+	}
+
+	client.mu.Lock()
+	client.SessionKey = loginResp.SessionKey
+	client.mu.Unlock()
+
+	return nil
+}
+
+// makes sure the client holds a session key, login if needed
+func ensureSession(client *SplunkClient) error {
+	if !client.SessionLogin {
+		return nil
+	}
+
+	client.mu.RLock()
+	sessionKey := client.SessionKey
+	client.mu.RUnlock()
+	if sessionKey != "" {
+		return nil
+	}
+
+	return refreshSession(client, "")
+}
+
+// login again unless another caller already replaced the expired session key
+func refreshSession(client *SplunkClient, expiredSessionKey string) error {
+	client.loginMu.Lock()
+	defer client.loginMu.Unlock()
+
+	client.mu.RLock()
+	sessionKey := client.SessionKey
+	client.mu.RUnlock()
+	if sessionKey != expiredSessionKey {
+		return nil
+	}
+
+	return Login(client)
+}
+
+// SetCredentials atomically replaces the credentials of the client, e.g. after a rotation
+// the session key of a client using the login flow is dropped if the username or password changed
+func (client *SplunkClient) SetCredentials(token string, sessionKey string, username string, password string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.SessionLogin && sessionKey == "" && username == client.Username && password == client.Password {
+		sessionKey = client.SessionKey
+	}
+
+	client.Token = token
+	client.SessionKey = sessionKey
+	client.Username = username
+	client.Password = password
+}
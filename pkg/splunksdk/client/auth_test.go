@@ -0,0 +1,120 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Builds a mock splunk server delivering session keys and accepting only the last one
+func buildLoginServer(logins *int32) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := fmt.Sprintf("key%d", atomic.LoadInt32(logins))
+
+		if strings.HasSuffix(r.URL.Path, loginPath) {
+			_ = r.ParseForm()
+			if r.Form.Get("username") != "admin" || r.Form.Get("password") != "changeme" || r.Header.Get("Authorization") != "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next := atomic.AddInt32(logins, 1)
+			_, _ = fmt.Fprintf(w, `{"sessionKey":"key%d"}`, next)
+			return
+		}
+		if r.Header.Get("Authorization") != "Splunk "+current {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(w, `{"messages":[{"type":"WARN","text":"call not properly authenticated"}]}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func newLoginTestClient(server *httptest.Server) *SplunkClient {
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "https://"))
+	client := NewClientAuthenticatedByLogin(&http.Client{Timeout: 5 * time.Second}, host, port, "admin", "changeme", true)
+	client.Endpoint = client.BuildEndpoint("services/search/v2/jobs/")
+
+	return client
+}
+
+func TestSessionLogin(t *testing.T) {
+	var logins int32
+	server := buildLoginServer(&logins)
+	defer server.Close()
+
+	client := newLoginTestClient(server)
+
+	resp, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || client.SessionKey != "key1" {
+		t.Fatalf("Expected a 200 response with the session key key1 but got %v with %v", resp.StatusCode, client.SessionKey)
+	}
+
+	// the session key expires on splunk side
+	atomic.AddInt32(&logins, 1)
+
+	resp, err = MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || client.SessionKey != "key3" {
+		t.Fatalf("Expected a 200 response after a new login but got %v with %v", resp.StatusCode, client.SessionKey)
+	}
+}
+
+func TestSessionLoginConcurrentCallers(t *testing.T) {
+	var logins int32
+	server := buildLoginServer(&logins)
+	defer server.Close()
+
+	client := newLoginTestClient(server)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+			switch {
+			case err != nil:
+				errs <- err
+			case resp.StatusCode != http.StatusOK:
+				errs <- fmt.Errorf("got status %v", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if logins != 1 {
+		t.Fatalf("Expected a single login for concurrent callers but got %v", logins)
+	}
+}
+
+func TestLoginWrongCredentials(t *testing.T) {
+	var logins int32
+	server := buildLoginServer(&logins)
+	defer server.Close()
+
+	client := newLoginTestClient(server)
+	client.Password = "wrong"
+
+	_, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if !IsUnauthorized(err) {
+		t.Fatalf("Expected an unauthorized error but got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"crypto/tls"
 	"net/http"
+	"sync"
 )
 
 type SplunkClient struct {
@@ -14,17 +15,29 @@ type SplunkClient struct {
 	Username   string
 	Password   string
 	SessionKey string
+	// scheme of the REST API, https if empty
+	Scheme string
+	// path prefix of the REST API, e.g. when splunk is behind a reverse proxy
+	BasePath string
 	// if true, ssl verification is skipped
 	SkipSSL bool
+	// retry policy for transient failures, no retries if nil
+	RetryPolicy *RetryPolicy
+	// fails fast while splunk is unavailable, disabled if nil
+	CircuitBreaker *CircuitBreaker
+	// if true, the username and password are exchanged for a session key instead of being sent on every request
+	SessionLogin bool
+
+	// guards the credentials which can be renewed while requests are made
+	mu sync.RWMutex
+	// serializes the logins
+	loginMu sync.Mutex
 }
 
 // create a new Client
 func NewClient(client *http.Client, host string, port string, token string, username string, password string, sessionKey string, skipSSL bool) *SplunkClient {
 	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
+		ConfigureTransport(client, &tls.Config{InsecureSkipVerify: true})
 	}
 
 	return &SplunkClient{
@@ -42,10 +55,7 @@ func NewClient(client *http.Client, host string, port string, token string, user
 // create a new client that could connect with authentication tokens
 func NewClientAuthenticatedByToken(client *http.Client, host string, port string, token string, skipSSL bool) *SplunkClient {
 	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
+		ConfigureTransport(client, &tls.Config{InsecureSkipVerify: true})
 	}
 
 	return &SplunkClient{
@@ -63,10 +73,7 @@ func NewClientAuthenticatedByToken(client *http.Client, host string, port string
 // create a new client that could connect with authentication sessionKey
 func NewClientAuthenticatedBySessionKey(client *http.Client, host string, port string, sessionKey string, skipSSL bool) *SplunkClient {
 	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
+		ConfigureTransport(client, &tls.Config{InsecureSkipVerify: true})
 	}
 
 	return &SplunkClient{
@@ -84,10 +91,7 @@ func NewClientAuthenticatedBySessionKey(client *http.Client, host string, port s
 // create a new client with basic authentication method
 func NewBasicAuthenticatedClient(client *http.Client, host string, port string, username string, password string, skipSSL bool) *SplunkClient {
 	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
+		ConfigureTransport(client, &tls.Config{InsecureSkipVerify: true})
 	}
 
 	return &SplunkClient{
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SplunkError is returned when the splunk REST api answers with a non 2xx status code
+type SplunkError struct {
+	// http status code of the response
+	StatusCode int
+	// type of the first message of the response (ERROR, WARN, FATAL...)
+	MessageType string
+	// texts of all the messages of the response
+	Messages []string
+	// endpoint called
+	Endpoint string
+}
+
+type splunkMessages struct {
+	Messages []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"messages"`
+}
+
+type hecMessage struct {
+	Text string `json:"text"`
+	Code int    `json:"code"`
+}
+
+func (e *SplunkError) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("splunk returned %s for %s", status, e.Endpoint)
+	}
+	return fmt.Sprintf("splunk returned %s for %s : %s", status, e.Endpoint, strings.Join(e.Messages, "; "))
+}
+
+// create a SplunkError from the response and its body
+func NewSplunkError(resp *http.Response, body []byte, endpoint string) *SplunkError {
+	spErr := &SplunkError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint,
+	}
+
+	var bodyJson splunkMessages
+	if json.Unmarshal(body, &bodyJson) == nil {
+		for i, message := range bodyJson.Messages {
+			if i == 0 {
+				spErr.MessageType = message.Type
+			}
+			spErr.Messages = append(spErr.Messages, message.Text)
+		}
+	}
+
+	// the HTTP Event Collector answers with a single text and code
+	var hecJson hecMessage
+	if len(spErr.Messages) == 0 && json.Unmarshal(body, &hecJson) == nil && hecJson.Text != "" {
+		spErr.Messages = append(spErr.Messages, fmt.Sprintf("%s (code %d)", hecJson.Text, hecJson.Code))
+	}
+	return spErr
+}
+
+// return nil if the response has a 2xx status code, a SplunkError otherwise
+func CheckHttpResponse(resp *http.Response, body []byte, endpoint string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return NewSplunkError(resp, body, endpoint)
+}
+
+// return the SplunkError wrapped in err, if any
+func AsSplunkError(err error) (*SplunkError, bool) {
+	var spErr *SplunkError
+	if errors.As(err, &spErr) {
+		return spErr, true
+	}
+	return nil, false
+}
+
+// check if splunk could not find the requested entity
+func IsNotFound(err error) bool {
+	spErr, ok := AsSplunkError(err)
+	return ok && spErr.StatusCode == http.StatusNotFound
+}
+
+// check if the entity to create already exists
+func IsConflict(err error) bool {
+	spErr, ok := AsSplunkError(err)
+	return ok && spErr.StatusCode == http.StatusConflict
+}
+
+// check if splunk rejected the credentials of the client
+func IsUnauthorized(err error) bool {
+	spErr, ok := AsSplunkError(err)
+	return ok && spErr.StatusCode == http.StatusUnauthorized
+}
+
+// check if the user of the client is not allowed to perform the request
+func IsForbidden(err error) bool {
+	spErr, ok := AsSplunkError(err)
+	return ok && spErr.StatusCode == http.StatusForbidden
+}
+
+// check if splunk refused the request because a search quota or a concurrency limit was reached
+func IsQuotaExceeded(err error) bool {
+	spErr, ok := AsSplunkError(err)
+	if !ok {
+		return false
+	}
+	if spErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if spErr.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	for _, message := range spErr.Messages {
+		message = strings.ToLower(message)
+		if strings.Contains(message, "quota") || strings.Contains(message, "concurrency limit") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewSplunkError(t *testing.T) {
+	body := []byte(`{"messages":[{"type":"ERROR","text":"Could not find object id=test"},{"type":"WARN","text":"second message"}]}`)
+	resp := &http.Response{StatusCode: http.StatusNotFound}
+
+	err := fmt.Errorf("alert Removing : http error : %w", CheckHttpResponse(resp, body, "https://localhost:8089/services/saved/searches/test"))
+
+	spErr, ok := AsSplunkError(err)
+	if !ok {
+		t.Fatalf("Expected a SplunkError to be wrapped in %v", err)
+	}
+	if spErr.StatusCode != http.StatusNotFound || spErr.MessageType != "ERROR" || len(spErr.Messages) != 2 {
+		t.Fatalf("Unexpected SplunkError content : %+v", spErr)
+	}
+	if !IsNotFound(err) || IsUnauthorized(err) || IsQuotaExceeded(err) {
+		t.Fatalf("Wrong classification of the error %v", err)
+	}
+}
+
+func TestCheckHttpResponse(t *testing.T) {
+	if err := CheckHttpResponse(&http.Response{StatusCode: http.StatusCreated}, nil, ""); err != nil {
+		t.Fatalf("No error expected for a 2xx response but got %v", err)
+	}
+
+	err := CheckHttpResponse(&http.Response{StatusCode: http.StatusUnauthorized}, []byte("not json"), "")
+	if !IsUnauthorized(err) {
+		t.Fatalf("Expected an unauthorized error but got %v", err)
+	}
+}
+
+func TestIsQuotaExceeded(t *testing.T) {
+	body := []byte(`{"messages":[{"type":"FATAL","text":"The maximum number of concurrent historical searches for this user based on their role quota has been reached."}]}`)
+
+	if !IsQuotaExceeded(NewSplunkError(&http.Response{StatusCode: http.StatusServiceUnavailable}, body, "")) {
+		t.Fatal("A 503 mentioning the quota should be a quota exceeded error")
+	}
+	if IsQuotaExceeded(NewSplunkError(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil, "")) {
+		t.Fatal("A 503 without message should not be a quota exceeded error")
+	}
+	if !IsQuotaExceeded(NewSplunkError(&http.Response{StatusCode: http.StatusTooManyRequests}, nil, "")) {
+		t.Fatal("A 429 should be a quota exceeded error")
+	}
+	if IsQuotaExceeded(fmt.Errorf("not a splunk error")) {
+		t.Fatal("Only splunk errors can be quota exceeded errors")
+	}
+}
+
+func TestHandleHttpError(t *testing.T) {
+	status, err := HandleHttpError([]byte(`{"messages":[{"type":"ERROR","text":"first"},{"type":"ERROR","text":"second"}]}`))
+	if err != nil || status != "first; second" {
+		t.Fatalf("Expected all the messages but got %v, %v", status, err)
+	}
+
+	_, err = HandleHttpError([]byte(`{"entry":[]}`))
+	if err == nil {
+		t.Fatal("Expected an error for a body without messages")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -17,6 +18,9 @@ import (
 //		3.  Basic Authentication
 func CreateAuthenticationKey(client *SplunkClient) (string, error) {
 
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
 	switch {
 	case client.Token != "":
 		switch {
@@ -43,49 +47,83 @@ func CreateAuthenticationKey(client *SplunkClient) (string, error) {
 	return "", fmt.Errorf("no authentication method provided")
 }
 
-// return the message of the error when got http error
+// return the messages of the error when got http error
 func HandleHttpError(body []byte) (string, error) {
 
-	var bodyJson map[string][]map[string]string
+	var bodyJson splunkMessages
 	errUmarshall := json.Unmarshal([]byte(body), &bodyJson)
 	if errUmarshall != nil {
 		return "", errUmarshall
 	}
 
-	if len(bodyJson) > 1 {
-		return bodyJson["messages"][0]["text"], nil
+	if len(bodyJson.Messages) == 0 {
+		return "", fmt.Errorf("incorrect format")
+	}
+
+	texts := make([]string, 0, len(bodyJson.Messages))
+	for _, message := range bodyJson.Messages {
+		texts = append(texts, message.Text)
 	}
-	return "", fmt.Errorf("incorrect format")
+	return strings.Join(texts, "; "), nil
 }
 
 // MakeHttpRequest creates a new http request - depending on the method (GET, POST, DELETE,...) - and returns the response
 func MakeHttpRequest(client *SplunkClient, method string, spRequestHeaders map[string]string, params url.Values) (*http.Response, error) {
+	return MakeHttpRequestWithBody(client, method, spRequestHeaders, params.Encode())
+}
+
+// MakeHttpRequestWithBody sends the body as is, e.g. json, instead of url encoded parameters
+func MakeHttpRequestWithBody(client *SplunkClient, method string, spRequestHeaders map[string]string, body string) (*http.Response, error) {
+	return MakeHttpRequestToEndpoint(client, method, client.Endpoint, spRequestHeaders, body)
+}
+
+// MakeHttpRequestToEndpoint sends the request to the given url instead of the endpoint of the client,
+// so that the requests sharing the client do not overwrite the url of each other
+func MakeHttpRequestToEndpoint(client *SplunkClient, method string, endpoint string, spRequestHeaders map[string]string, body string) (*http.Response, error) {
 
-	// create a new request
-	req, err := http.NewRequest(method, client.Endpoint, strings.NewReader(params.Encode()))
-	if err != nil {
-		return nil, err
-	}
 	// add the headers
 	if spRequestHeaders == nil {
 		spRequestHeaders = map[string]string{}
 	}
 
+	err := ensureSession(client)
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := CreateAuthenticationKey(client)
 	if err != nil {
 		return nil, err
 	}
 	spRequestHeaders["Authorization"] = token
 
-	for header, val := range spRequestHeaders {
-		req.Header.Add(header, val)
-	}
-	// get the response
-	resp, err := client.Client.Do(req)
+	// get the response, retrying transient failures if a retry policy is set
+	resp, err := doWithRetry(client, method, endpoint, spRequestHeaders, body)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// the session key has expired, login again and replay the request once
+	if resp.StatusCode == http.StatusUnauthorized && client.SessionLogin {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		err = refreshSession(client, strings.TrimPrefix(token, "Splunk "))
+		if err != nil {
+			return nil, err
+		}
+		token, err = CreateAuthenticationKey(client)
+		if err != nil {
+			return nil, err
+		}
+		spRequestHeaders["Authorization"] = token
+
+		resp, err = doWithRetry(client, method, endpoint, spRequestHeaders, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return resp, nil
 }
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker refuses a request because splunk is considered down
+var ErrCircuitOpen = errors.New("splunk circuit breaker is open, request not sent")
+
+type RetryPolicy struct {
+	// maximum number of attempts for a request (the first attempt included)
+	MaxAttempts int
+	// backoff before the first retry
+	InitialBackoff time.Duration
+	// upper bound of the backoff between two attempts
+	MaxBackoff time.Duration
+	// factor applied to the backoff after each attempt
+	Multiplier float64
+	// fraction of the backoff randomly added or removed (between 0 and 1)
+	Jitter float64
+}
+
+// create a retry policy with the default values
+func NewRetryPolicy(maxAttempts int, initialBackoff time.Duration, maxBackoff time.Duration) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// return the time to wait before the next attempt (attempt starts at 1)
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if wait < 0 {
+		return 0
+	}
+	return time.Duration(wait)
+}
+
+// return the number of attempts allowed by the policy
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// check if a request can be sent again without side effects
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodPut:
+		return true
+	}
+	return false
+}
+
+// check if the request should be retried given its response or error
+//
+//	429 and 503 are always retried since splunk did not process the request,
+//	other 5xx and network errors are only retried for idempotent methods
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return isIdempotent(method)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		return true
+	case resp.StatusCode >= 500:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+// return the delay asked by the server in the Retry-After header, if any
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker stops sending requests to splunk after too many consecutive failures
+// and lets a single probe request through once the cooldown has elapsed
+type CircuitBreaker struct {
+	// number of consecutive failures opening the circuit
+	FailureThreshold int
+	// time during which requests fail fast once the circuit is open
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// create a new closed circuit breaker
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		Cooldown:         cooldown,
+	}
+}
+
+// check if a request may be sent
+func (cb *CircuitBreaker) Allow() bool {
+	if cb == nil {
+		return true
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if time.Since(cb.openedAt) < cb.Cooldown {
+			return false
+		}
+		// let a probe request go through
+		cb.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// a probe is already in flight
+		return false
+	}
+	return true
+}
+
+// check if the circuit is currently refusing requests, during the cooldown or while a probe is in flight
+func (cb *CircuitBreaker) IsOpen() bool {
+	if cb == nil {
+		return false
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		return time.Since(cb.openedAt) < cb.Cooldown
+	case circuitHalfOpen:
+		return true
+	}
+	return false
+}
+
+// close the circuit after a successful request
+func (cb *CircuitBreaker) RecordSuccess() {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.state = circuitClosed
+}
+
+// count a failed request and open the circuit if the threshold is reached
+func (cb *CircuitBreaker) RecordFailure() {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || (cb.FailureThreshold > 0 && cb.failures >= cb.FailureThreshold) {
+		cb.state = circuitOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// record the outcome of a request: network errors, 429 and 5xx are failures
+func (cb *CircuitBreaker) record(resp *http.Response, err error) {
+	if err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		cb.RecordFailure()
+		return
+	}
+	cb.RecordSuccess()
+}
+
+// sends the request following the retry policy and the circuit breaker of the client
+func doWithRetry(client *SplunkClient, method string, endpoint string, headers map[string]string, body string) (*http.Response, error) {
+
+	attempts := client.RetryPolicy.attempts()
+
+	for attempt := 1; ; attempt++ {
+		// the request is built first, an invalid request must not take the probe of a half-open circuit
+		req, err := http.NewRequest(method, endpoint, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for header, val := range headers {
+			req.Header.Add(header, val)
+		}
+
+		if !client.CircuitBreaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := client.Client.Do(req)
+		client.CircuitBreaker.record(resp, err)
+
+		if attempt >= attempts || !shouldRetry(method, resp, err) {
+			return resp, err
+		}
+
+		wait := client.RetryPolicy.backoff(attempt)
+		if delay, ok := retryAfter(resp); ok {
+			// do not block the caller longer than the policy allows
+			if client.RetryPolicy.MaxBackoff > 0 && delay > client.RetryPolicy.MaxBackoff {
+				return resp, err
+			}
+			wait = delay
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		time.Sleep(wait)
+	}
+}
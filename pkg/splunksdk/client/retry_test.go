@@ -0,0 +1,153 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Builds a mock splunk server answering with the given status codes, one per request
+func buildStatusServer(statusCodes []int, calls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statusCodes[len(statusCodes)-1]
+		if *calls < len(statusCodes) {
+			status = statusCodes[*calls]
+		}
+		*calls++
+		w.WriteHeader(status)
+	}))
+}
+
+func TestMakeHttpRequestRetriesTransientErrors(t *testing.T) {
+	calls := 0
+	server := buildStatusServer([]int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, &calls)
+	defer server.Close()
+
+	client := NewClientAuthenticatedByToken(&http.Client{Timeout: 5 * time.Second}, "", "", "token", false)
+	client.Endpoint = server.URL
+	client.RetryPolicy = NewRetryPolicy(3, time.Millisecond, 5*time.Millisecond)
+
+	resp, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("Expected a 200 response after 3 calls but got %v after %v calls", resp.StatusCode, calls)
+	}
+}
+
+func TestMakeHttpRequestDoesNotRetryNonIdempotentRequests(t *testing.T) {
+	calls := 0
+	server := buildStatusServer([]int{http.StatusInternalServerError, http.StatusOK}, &calls)
+	defer server.Close()
+
+	client := NewClientAuthenticatedByToken(&http.Client{Timeout: 5 * time.Second}, "", "", "token", false)
+	client.Endpoint = server.URL
+	client.RetryPolicy = NewRetryPolicy(3, time.Millisecond, 5*time.Millisecond)
+
+	resp, err := MakeHttpRequest(client, http.MethodPost, nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError || calls != 1 {
+		t.Fatalf("Expected a single 500 response but got %v after %v calls", resp.StatusCode, calls)
+	}
+
+	// a 429 means the request was not processed, it is retried whatever the method
+	calls = 0
+	server429 := buildStatusServer([]int{http.StatusTooManyRequests, http.StatusOK}, &calls)
+	defer server429.Close()
+	client.Endpoint = server429.URL
+
+	resp, err = MakeHttpRequest(client, http.MethodPost, nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Fatalf("Expected a 200 response after 2 calls but got %v after %v calls", resp.StatusCode, calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	if _, ok := retryAfter(resp); ok {
+		t.Fatal("No delay expected without Retry-After header")
+	}
+
+	resp.Header.Set("Retry-After", "2")
+	if delay, ok := retryAfter(resp); !ok || delay != 2*time.Second {
+		t.Fatalf("Expected a 2s delay but got %v", delay)
+	}
+
+	resp.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	if delay, ok := retryAfter(resp); !ok || delay != 0 {
+		t.Fatalf("Expected no delay for a date in the past but got %v", delay)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	calls := 0
+	server := buildStatusServer([]int{http.StatusInternalServerError}, &calls)
+	defer server.Close()
+
+	client := NewClientAuthenticatedByToken(&http.Client{Timeout: 5 * time.Second}, "", "", "token", false)
+	client.Endpoint = server.URL
+	client.CircuitBreaker = NewCircuitBreaker(2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		_, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+		if err != nil {
+			t.Fatalf("Got an error : %s", err)
+		}
+	}
+	if !client.CircuitBreaker.IsOpen() {
+		t.Fatal("The circuit breaker should be open after 2 failures")
+	}
+
+	_, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err != ErrCircuitOpen {
+		t.Fatalf("Expected %v but got %v", ErrCircuitOpen, err)
+	}
+	if calls != 2 {
+		t.Fatalf("No request should reach splunk while the circuit is open, got %v calls", calls)
+	}
+
+	// once the cooldown is over, a successful probe closes the circuit
+	client.CircuitBreaker.Cooldown = 0
+	client.CircuitBreaker.RecordSuccess()
+	if client.CircuitBreaker.IsOpen() || !client.CircuitBreaker.Allow() {
+		t.Fatal("The circuit breaker should be closed after a success")
+	}
+}
+
+// Tests that an invalid request does not leave the circuit half-open and that a half-open circuit is reported as open
+func TestCircuitBreakerInvalidRequest(t *testing.T) {
+	calls := 0
+	server := buildStatusServer([]int{http.StatusOK}, &calls)
+	defer server.Close()
+
+	client := NewClientAuthenticatedByToken(&http.Client{Timeout: 5 * time.Second}, "", "", "token", false)
+	client.CircuitBreaker = NewCircuitBreaker(1, 0)
+	client.CircuitBreaker.RecordFailure()
+
+	client.Endpoint = "http://[::1"
+	_, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err == nil || err == ErrCircuitOpen {
+		t.Fatalf("Expected the error of the invalid request but got %v", err)
+	}
+
+	// the cooldown is over, the next valid request is the probe closing the circuit
+	client.Endpoint = server.URL
+	_, err = MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err != nil || calls != 1 || client.CircuitBreaker.IsOpen() {
+		t.Fatalf("Expected the probe to reach splunk and close the circuit but got %v after %v calls", err, calls)
+	}
+
+	// a probe in flight refuses the other requests
+	client.CircuitBreaker.RecordFailure()
+	if !client.CircuitBreaker.Allow() || !client.CircuitBreaker.IsOpen() {
+		t.Fatal("Expected the half-open circuit to be reported as open")
+	}
+}
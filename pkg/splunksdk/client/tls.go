@@ -0,0 +1,103 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+type TLSOptions struct {
+	// PEM bundle of the certificate authorities trusted in addition to the system ones
+	CACertPath string
+	// PEM client certificate and key for mutual TLS
+	ClientCertPath string
+	ClientKeyPath  string
+	// name used to verify the certificate of splunk instead of its host
+	ServerName string
+	// minimum TLS version accepted : 1.0, 1.1, 1.2 or 1.3
+	MinVersion string
+	// if true, the certificate of splunk is not verified
+	InsecureSkipVerify bool
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// build the tls configuration described by the options
+func BuildTLSConfig(opts TLSOptions) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.MinVersion != "" {
+		version, ok := tlsVersions[opts.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %s", opts.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if opts.CACertPath != "" {
+		caBundle, err := os.ReadFile(opts.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA bundle %s : %w", opts.CACertPath, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no valid certificate found in the CA bundle %s", opts.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	switch {
+	case opts.ClientCertPath != "" && opts.ClientKeyPath != "":
+		certificate, err := tls.LoadX509KeyPair(opts.ClientCertPath, opts.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate : %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	case opts.ClientCertPath != "" || opts.ClientKeyPath != "":
+		return nil, fmt.Errorf("both a client certificate and a client key are required for mutual TLS")
+	}
+
+	return tlsConfig, nil
+}
+
+// set the tls configuration of the http client, keeping the other settings of its transport
+func ConfigureTransport(client *http.Client, tlsConfig *tls.Config) {
+	var transport *http.Transport
+
+	switch tr := client.Transport.(type) {
+	case *http.Transport:
+		transport = tr.Clone()
+	default:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client.Transport = transport
+}
+
+// apply the tls options to the client
+func ConfigureTLS(client *SplunkClient, opts TLSOptions) error {
+	tlsConfig, err := BuildTLSConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	ConfigureTransport(client.Client, tlsConfig)
+	client.SkipSSL = opts.InsecureSkipVerify
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Writes the certificate of the test server in a PEM file and returns its path
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	caPath := filepath.Join(t.TempDir(), "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	err := os.WriteFile(caPath, caPEM, 0600)
+	if err != nil {
+		t.Fatalf("Could not write the CA bundle : %v", err)
+	}
+	return caPath
+}
+
+func TestConfigureTLSWithCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClientAuthenticatedByToken(&http.Client{Timeout: 5 * time.Second}, "", "", "token", false)
+	client.Endpoint = server.URL
+
+	// the certificate of the test server is not trusted by default
+	_, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err == nil {
+		t.Fatal("Expected the verification of an unknown certificate to fail")
+	}
+
+	err = ConfigureTLS(client, TLSOptions{CACertPath: writeServerCA(t, server), ServerName: "example.com", MinVersion: "1.2"})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	resp, err := MakeHttpRequest(client, http.MethodGet, nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected a 200 response but got %v", resp.StatusCode)
+	}
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	if _, err := BuildTLSConfig(TLSOptions{MinVersion: "2.0"}); err == nil {
+		t.Fatal("Expected an error for an unknown TLS version")
+	}
+	if _, err := BuildTLSConfig(TLSOptions{CACertPath: filepath.Join(t.TempDir(), "missing.crt")}); err == nil {
+		t.Fatal("Expected an error for a missing CA bundle")
+	}
+	if _, err := BuildTLSConfig(TLSOptions{ClientCertPath: "tls.crt"}); err == nil {
+		t.Fatal("Expected an error for a client certificate without key")
+	}
+
+	tlsConfig, err := BuildTLSConfig(TLSOptions{MinVersion: "1.3"})
+	if err != nil || tlsConfig.MinVersion != tls.VersionTLS13 || tlsConfig.InsecureSkipVerify {
+		t.Fatalf("Unexpected tls configuration %+v, %v", tlsConfig, err)
+	}
+}
+
+func TestConfigureTransportKeepsSettings(t *testing.T) {
+	proxy := http.ProxyURL(&url.URL{Scheme: "http", Host: "proxy:3128"})
+	httpClient := &http.Client{Transport: &http.Transport{Proxy: proxy, MaxIdleConns: 7}}
+
+	ConfigureTransport(httpClient, &tls.Config{InsecureSkipVerify: true})
+
+	transport := httpClient.Transport.(*http.Transport)
+	if transport.Proxy == nil || transport.MaxIdleConns != 7 || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("The settings of the existing transport should be kept")
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// SplunkURL is the parsed address of a splunk REST API, e.g. https://splunk.example.com:8089/base
+type SplunkURL struct {
+	Scheme string
+	Host   string
+	// port of the url, the default port of the scheme if the url has none
+	Port string
+	// path prefix of the REST API without leading and trailing slashes
+	BasePath string
+}
+
+// default ports of the schemes of the urls without port
+var defaultPorts = map[string]string{"https": "443", "http": "80"}
+
+// parse the address of a splunk REST API, the scheme defaults to https and the port to the one of the scheme
+func ParseSplunkURL(rawURL string) (*SplunkURL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid splunk url %s: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid splunk url %s: the scheme must be http or https", rawURL)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid splunk url %s: no host", rawURL)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPorts[u.Scheme]
+	}
+
+	return &SplunkURL{
+		Scheme:   u.Scheme,
+		Host:     u.Hostname(),
+		Port:     port,
+		BasePath: strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+// point the client to the given splunk url, see ParseSplunkURL
+func (client *SplunkClient) SetURL(rawURL string) error {
+	splunkURL, err := ParseSplunkURL(rawURL)
+	if err != nil {
+		return err
+	}
+
+	client.Scheme = splunkURL.Scheme
+	client.Host = splunkURL.Host
+	client.BasePath = splunkURL.BasePath
+	client.Port = splunkURL.Port
+
+	return nil
+}
+
+// return the url of the given splunk REST service
+//
+//	the scheme is the one of the client, or of the host if it has one, and defaults to https
+func (client *SplunkClient) BuildEndpoint(service string) string {
+	scheme := client.Scheme
+	host := strings.ReplaceAll(client.Host, " ", "")
+
+	for _, hostScheme := range []string{"https", "http"} {
+		if strings.HasPrefix(host, hostScheme+"://") {
+			host = strings.TrimPrefix(host, hostScheme+"://")
+			if scheme == "" {
+				scheme = hostScheme
+			}
+		}
+	}
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	// IPv6 hosts may be given with or without brackets
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	address := host
+	switch {
+	case client.Port != "":
+		address = net.JoinHostPort(host, client.Port)
+	case strings.Contains(host, ":") && net.ParseIP(host) != nil:
+		address = "[" + host + "]"
+	}
+
+	path := "/"
+	if basePath := strings.Trim(client.BasePath, "/"); basePath != "" {
+		path += basePath + "/"
+	}
+
+	endpoint := scheme + "://" + address + path + service
+	return strings.ReplaceAll(endpoint, " ", "")
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBuildEndpoint(t *testing.T) {
+	testCases := []struct {
+		client   *SplunkClient
+		expected string
+	}{
+		{&SplunkClient{Host: "splunk.example.com", Port: "8089"}, "https://splunk.example.com:8089/services/search/jobs"},
+		{&SplunkClient{Host: "https://splunk.example.com", Port: "8089"}, "https://splunk.example.com:8089/services/search/jobs"},
+		{&SplunkClient{Host: "http://localhost", Port: "8000"}, "http://localhost:8000/services/search/jobs"},
+		{&SplunkClient{Host: "::1", Port: "8089"}, "https://[::1]:8089/services/search/jobs"},
+		{&SplunkClient{Host: "[2001:db8::1]", Port: "8089"}, "https://[2001:db8::1]:8089/services/search/jobs"},
+		{&SplunkClient{Host: "2001:db8::1"}, "https://[2001:db8::1]/services/search/jobs"},
+		{&SplunkClient{Host: "proxy.example.com", Scheme: "http", BasePath: "/splunk/"}, "http://proxy.example.com/splunk/services/search/jobs"},
+	}
+
+	for _, testCase := range testCases {
+		endpoint := testCase.client.BuildEndpoint("services/search/jobs")
+		if endpoint != testCase.expected {
+			t.Errorf("Expected %s for %s but got %s", testCase.expected, testCase.client.Host, endpoint)
+		}
+	}
+}
+
+func TestSetURL(t *testing.T) {
+	client := NewClientAuthenticatedByToken(&http.Client{}, "", "8089", "token", false)
+
+	err := client.SetURL("http://[::1]:8000/base/")
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if endpoint := client.BuildEndpoint("services/auth/login"); endpoint != "http://[::1]:8000/base/services/auth/login" {
+		t.Fatalf("Unexpected endpoint %s", endpoint)
+	}
+
+	// the scheme defaults to https and the port to the one of the scheme, not to the port of the client
+	client = NewClientAuthenticatedByToken(&http.Client{}, "", "8089", "token", false)
+	err = client.SetURL("splunk.example.com/api")
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if endpoint := client.BuildEndpoint("services"); endpoint != "https://splunk.example.com:443/api/services" {
+		t.Fatalf("Unexpected endpoint %s", endpoint)
+	}
+	err = client.SetURL("http://proxy.example.com/splunk")
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if endpoint := client.BuildEndpoint("services"); endpoint != "http://proxy.example.com:80/splunk/services" {
+		t.Fatalf("Unexpected endpoint %s", endpoint)
+	}
+
+	for _, invalidURL := range []string{"ftp://splunk.example.com", "https://", "https://splunk:port"} {
+		if err := client.SetURL(invalidURL); err == nil {
+			t.Errorf("Expected an error for %s", invalidURL)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package hec
+
+import "time"
+
+// Annotation is a marker overlaid on the charts of the dashboards, e.g. a deployment
+//
+//	it is sent as an event with the annotation_label, annotation_category and annotation_color fields
+//	used by the annotation searches of the dashboards
+type Annotation struct {
+	Time     time.Time
+	Label    string
+	Category string
+	// color of the marker, e.g. #4CAF50
+	Color string
+	// additional fields of the event
+	Fields map[string]string
+}
+
+// create the event of the annotation
+func NewAnnotationEvent(annotation Annotation) Event {
+	content := map[string]string{}
+	for name, value := range annotation.Fields {
+		if value != "" {
+			content[name] = value
+		}
+	}
+	content["annotation_label"] = annotation.Label
+	if annotation.Category != "" {
+		content["annotation_category"] = annotation.Category
+	}
+	if annotation.Color != "" {
+		content["annotation_color"] = annotation.Color
+	}
+
+	event := Event{Event: content}
+	if !annotation.Time.IsZero() {
+		event.Time = float64(annotation.Time.UnixMilli()) / 1000
+	}
+	return event
+}
@@ -0,0 +1,90 @@
+package hec
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+// Batcher groups the events and sends them when the batch is full or at every flush interval
+type Batcher struct {
+	client        *splunk.SplunkClient
+	maxSize       int
+	flushInterval time.Duration
+	// called with the error and the number of lost events when a batch could not be sent
+	OnError func(err error, events int)
+
+	mu      sync.Mutex
+	pending []Event
+	// serializes the requests as they share the endpoint of the client
+	sendMu sync.Mutex
+}
+
+// create a batcher, the events are sent one by one if maxSize is lower than 2
+func NewBatcher(client *splunk.SplunkClient, maxSize int, flushInterval time.Duration) *Batcher {
+	if maxSize < 1 {
+		maxSize = 1
+	}
+	return &Batcher{
+		client:        client,
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+	}
+}
+
+// queue the event, the batch is sent in the background if it is full
+func (b *Batcher) Add(event Event) {
+	b.mu.Lock()
+	b.pending = append(b.pending, event)
+	full := len(b.pending) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		go func() { _ = b.Flush() }()
+	}
+}
+
+// send the pending events
+func (b *Batcher) Flush() error {
+	b.mu.Lock()
+	events := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	b.sendMu.Lock()
+	err := SendEvents(b.client, events)
+	b.sendMu.Unlock()
+
+	if err != nil && b.OnError != nil {
+		b.OnError(err, len(events))
+	}
+	return err
+}
+
+// flush the pending events at every flush interval until the context is done, then flush a last time
+func (b *Batcher) Run(ctx context.Context) {
+	if b.flushInterval <= 0 {
+		<-ctx.Done()
+		_ = b.Flush()
+		return
+	}
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = b.Flush()
+			return
+		case <-ticker.C:
+			_ = b.Flush()
+		}
+	}
+}
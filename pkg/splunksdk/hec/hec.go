@@ -0,0 +1,83 @@
+package hec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+const eventPath = "services/collector/event"
+
+// Event is an event sent to the HTTP Event Collector
+type Event struct {
+	// epoch time of the event in seconds, the time of reception if 0
+	Time       float64 `json:"time,omitempty"`
+	Host       string  `json:"host,omitempty"`
+	Source     string  `json:"source,omitempty"`
+	SourceType string  `json:"sourcetype,omitempty"`
+	Index      string  `json:"index,omitempty"`
+	// content of the event, "metric" for a metric event
+	Event interface{} `json:"event"`
+	// indexed fields of the event or measures of a metric event
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// create a client sending events to the HTTP Event Collector at the given url (e.g. https://splunk.example.com:8088)
+//
+//	the port defaults to the one of the scheme, like for the REST API
+//
+//	the HEC token is sent as "Authorization: Splunk <token>", like a session key
+func NewClient(client *http.Client, hecURL string, token string, skipSSL bool) (*splunk.SplunkClient, error) {
+	hecClient := splunk.NewClientAuthenticatedBySessionKey(client, "", "", token, skipSSL)
+
+	err := hecClient.SetURL(hecURL)
+	if err != nil {
+		return nil, err
+	}
+	return hecClient, nil
+}
+
+// Sends the events to the HTTP Event Collector in a single request
+func SendEvents(client *splunk.SplunkClient, events []Event) error {
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	// the collector accepts several json objects one after the other
+	var body strings.Builder
+	for _, event := range events {
+		content, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("hec : error while encoding the event : %w", err)
+		}
+		body.Write(content)
+		body.WriteString("\n")
+	}
+
+	endpoint := client.BuildEndpoint(eventPath)
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	resp, err := splunk.MakeHttpRequestToEndpoint(client, http.MethodPost, endpoint, headers, body.String())
+	if err != nil {
+		return fmt.Errorf("hec : error while making the post request : %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("hec : error while getting the body of the post request : %w", err)
+	}
+	// handle error
+	err = splunk.CheckHttpResponse(resp, respBody, endpoint)
+	if err != nil {
+		return fmt.Errorf("hec : http error : %w", err)
+	}
+
+	return nil
+}
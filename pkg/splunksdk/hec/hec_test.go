@@ -0,0 +1,136 @@
+package hec
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Builds a fake HTTP Event Collector recording the events it receives
+func buildMockCollector(t *testing.T, events *[]Event, mu *sync.Mutex) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+eventPath {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Splunk hecToken" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"text":"Invalid token","code":4}`))
+			return
+		}
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var event Event
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Errorf("Invalid event %s : %v", scanner.Text(), err)
+			}
+			mu.Lock()
+			*events = append(*events, event)
+			mu.Unlock()
+		}
+		_, _ = w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+}
+
+func TestSendEvents(t *testing.T) {
+	var received []Event
+	var mu sync.Mutex
+	server := buildMockCollector(t, &received, &mu)
+	defer server.Close()
+
+	client, err := NewClient(&http.Client{}, server.URL, "hecToken", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = SendEvents(client, []Event{
+		{Index: "keptn", SourceType: "keptn:event", Event: map[string]string{"type": "sh.keptn.event.deployment.finished"}},
+		{Index: "keptn", SourceType: "keptn:event", Event: map[string]string{"type": "sh.keptn.event.evaluation.finished"}},
+	})
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if len(received) != 2 || received[1].Index != "keptn" || received[1].SourceType != "keptn:event" {
+		t.Fatalf("Unexpected events received : %+v", received)
+	}
+
+	// the message of the collector is returned on errors
+	client, _ = NewClient(&http.Client{}, server.URL, "wrongToken", true)
+	err = SendEvents(client, []Event{{Event: "test"}})
+	if err == nil || !strings.Contains(err.Error(), "Invalid token") {
+		t.Fatalf("Expected an invalid token error but got %v", err)
+	}
+}
+
+func TestBatcher(t *testing.T) {
+	var received []Event
+	var mu sync.Mutex
+	server := buildMockCollector(t, &received, &mu)
+	defer server.Close()
+
+	client, err := NewClient(&http.Client{}, server.URL, "hecToken", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batcher := NewBatcher(client, 3, time.Hour)
+	batcher.Add(Event{Event: "first"})
+	batcher.Add(Event{Event: "second"})
+
+	mu.Lock()
+	if len(received) != 0 {
+		t.Fatalf("Expected the events to wait for a full batch but got %d", len(received))
+	}
+	mu.Unlock()
+
+	// the third event fills the batch
+	batcher.Add(Event{Event: "third"})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		count := len(received)
+		mu.Unlock()
+		if count == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 3 events but got %d", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	batcher.Add(Event{Event: "fourth"})
+	if err := batcher.Flush(); err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 4 {
+		t.Fatalf("Expected the pending event to be flushed but got %d events", len(received))
+	}
+}
+
+func TestNewAnnotationEvent(t *testing.T) {
+	event := NewAnnotationEvent(Annotation{
+		Time:     time.Unix(1610722992, 0),
+		Label:    "Deployment of helloservice",
+		Category: "deployment",
+		Fields:   map[string]string{"version": "v0.1.1", "image": ""},
+	})
+
+	content, ok := event.Event.(map[string]string)
+	if !ok || event.Time != 1610722992 {
+		t.Fatalf("Unexpected annotation event %+v", event)
+	}
+	if content["annotation_label"] != "Deployment of helloservice" || content["annotation_category"] != "deployment" || content["version"] != "v0.1.1" {
+		t.Fatalf("Unexpected annotation fields %v", content)
+	}
+	if _, ok := content["image"]; ok {
+		t.Fatal("Expected the empty fields to be omitted")
+	}
+}
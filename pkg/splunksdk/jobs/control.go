@@ -0,0 +1,207 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+const controlUri = "control"
+
+// dispatch states of a job
+const (
+	DispatchStateQueued     = "QUEUED"
+	DispatchStateParsing    = "PARSING"
+	DispatchStateRunning    = "RUNNING"
+	DispatchStatePaused     = "PAUSED"
+	DispatchStateFinalizing = "FINALIZING"
+	DispatchStateFailed     = "FAILED"
+	DispatchStateDone       = "DONE"
+)
+
+// JobStatus is the state of a search job
+type JobStatus struct {
+	Sid           string `json:"sid"`
+	DispatchState string `json:"dispatchState"`
+	// between 0 and 1
+	DoneProgress float64 `json:"doneProgress"`
+	ResultCount  int     `json:"resultCount"`
+	// in seconds
+	RunDuration float64 `json:"runDuration"`
+	IsDone      bool    `json:"isDone"`
+	IsFailed    bool    `json:"isFailed"`
+	IsPaused    bool    `json:"isPaused"`
+	IsFinalized bool    `json:"isFinalized"`
+	// time to live of the job in seconds once it is done
+	TTL      int       `json:"ttl"`
+	Search   string    `json:"search"`
+	Messages []Message `json:"messages"`
+}
+
+type jobEntries struct {
+	Entry []struct {
+		Content JobStatus `json:"content"`
+	} `json:"entry"`
+}
+
+// return the state of the job
+func GetJobStatus(client *splunk.SplunkClient, sid string) (*JobStatus, error) {
+
+	body, err := jobRequest(client, http.MethodGet, jobsPathv2+url.PathEscape(sid), url.Values{"output_mode": {"json"}})
+	if err != nil {
+		return nil, fmt.Errorf("job status : %w", err)
+	}
+
+	var entries jobEntries
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("could not map the job status to datastructure: %w", err)
+	}
+	if len(entries.Entry) == 0 {
+		return nil, fmt.Errorf("job status : no job %s", sid)
+	}
+	return &entries.Entry[0].Content, nil
+}
+
+// return the jobs visible to the user of the client
+func ListJobs(client *splunk.SplunkClient) ([]JobStatus, error) {
+
+	body, err := jobRequest(client, http.MethodGet, jobsPathv2, url.Values{"output_mode": {"json"}, "count": {"0"}})
+	if err != nil {
+		return nil, fmt.Errorf("jobs listing : %w", err)
+	}
+
+	var entries jobEntries
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("could not map the list of jobs to datastructure: %w", err)
+	}
+
+	jobs := make([]JobStatus, 0, len(entries.Entry))
+	for _, entry := range entries.Entry {
+		jobs = append(jobs, entry.Content)
+	}
+	return jobs, nil
+}
+
+// Stops the job and deletes its results
+func CancelJob(client *splunk.SplunkClient, sid string) error {
+	return controlJob(client, sid, url.Values{"action": {"cancel"}})
+}
+
+func PauseJob(client *splunk.SplunkClient, sid string) error {
+	return controlJob(client, sid, url.Values{"action": {"pause"}})
+}
+
+func UnpauseJob(client *splunk.SplunkClient, sid string) error {
+	return controlJob(client, sid, url.Values{"action": {"unpause"}})
+}
+
+// Stops the job and keeps the results computed so far
+func FinalizeJob(client *splunk.SplunkClient, sid string) error {
+	return controlJob(client, sid, url.Values{"action": {"finalize"}})
+}
+
+// Sets the time during which the job is kept once it is done
+func SetTTL(client *splunk.SplunkClient, sid string, ttl time.Duration) error {
+	return controlJob(client, sid, url.Values{"action": {"setttl"}, "ttl": {strconv.Itoa(int(ttl.Seconds()))}})
+}
+
+// Deletes the job, it is cancelled if it is still running
+func DeleteJob(client *splunk.SplunkClient, sid string) error {
+
+	_, err := jobRequest(client, http.MethodDelete, jobsPathv2+url.PathEscape(sid), url.Values{"output_mode": {"json"}})
+	if err != nil {
+		return fmt.Errorf("job removing : %w", err)
+	}
+	return nil
+}
+
+// Waits until the job is done, checking its state at every interval
+//
+//	the job is cancelled if the context is done before, so that an abandoned search does not keep running in splunk
+func WaitForJob(ctx context.Context, client *splunk.SplunkClient, sid string, interval time.Duration) (*JobStatus, error) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := GetJobStatus(client, sid)
+		if err != nil {
+			return nil, err
+		}
+		if status.IsFailed || status.DispatchState == DispatchStateFailed {
+			err = (&Results{Messages: status.Messages}).Err()
+			if err == nil {
+				err = fmt.Errorf("no error message")
+			}
+			return status, fmt.Errorf("job %s failed : %w", sid, err)
+		}
+		if status.IsDone {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			errCancel := CancelJob(client, sid)
+			if errCancel != nil {
+				return status, fmt.Errorf("job %s abandoned (%v) but could not be cancelled : %w", sid, ctx.Err(), errCancel)
+			}
+			return status, fmt.Errorf("job %s cancelled : %w", sid, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// send an action to the control endpoint of the job
+func controlJob(client *splunk.SplunkClient, sid string, params url.Values) error {
+
+	params.Add("output_mode", "json")
+	_, err := jobRequest(client, http.MethodPost, jobsPathv2+url.PathEscape(sid)+"/"+controlUri, params)
+	if err != nil {
+		return fmt.Errorf("job %s : %w", params.Get("action"), err)
+	}
+	return nil
+}
+
+// sends the request and returns the body of the response, the parameters are sent in the url except for the post requests
+func jobRequest(client *splunk.SplunkClient, method string, path string, params url.Values) ([]byte, error) {
+
+	// create the endpoint for the request, a local value since the client is shared by concurrent requests
+	endpoint := client.BuildEndpoint(path)
+
+	headers := map[string]string{}
+	body := ""
+	if method == http.MethodPost {
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+		body = params.Encode()
+	} else if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	resp, err := splunk.MakeHttpRequestToEndpoint(client, method, endpoint, headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("error while making the %s request : %w", method, err)
+	}
+
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the body of the %s request : %w", method, err)
+	}
+	// handle error
+	err = splunk.CheckHttpResponse(resp, respBody, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http error : %w", err)
+	}
+
+	return respBody, nil
+}
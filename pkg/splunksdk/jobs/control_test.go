@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	splunkTest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+// fake splunk job, done after the given number of status requests
+type mockJob struct {
+	mu           sync.Mutex
+	statusChecks int
+	doneAfter    int
+	actions      []string
+	deleted      bool
+	// form of the dispatch of the saved search
+	dispatched url.Values
+}
+
+func (j *mockJob) server(t *testing.T) *httptest.Server {
+	const jobPath = "/" + splunkTest.JobsPathv2 + "1689673231.191"
+
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/"+splunkTest.JobsPathv2 && r.Method == http.MethodPost:
+			_, _ = fmt.Fprint(w, `{"sid":"1689673231.191"}`)
+		case r.URL.Path == "/"+splunkTest.JobsPathv2 && r.Method == http.MethodGet:
+			_, _ = fmt.Fprint(w, `{"entry":[{"content":{"sid":"1689673231.191","dispatchState":"RUNNING"}},{"content":{"sid":"1689673240.192","dispatchState":"DONE","isDone":true}}]}`)
+		case r.URL.Path == jobPath && r.Method == http.MethodGet:
+			j.statusChecks++
+			if j.doneAfter > 0 && j.statusChecks >= j.doneAfter {
+				_, _ = fmt.Fprint(w, `{"entry":[{"content":{"sid":"1689673231.191","dispatchState":"DONE","isDone":true,"doneProgress":1,"resultCount":1,"runDuration":0.42}}]}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"entry":[{"content":{"sid":"1689673231.191","dispatchState":"RUNNING","doneProgress":0.3}}]}`)
+		case r.URL.Path == jobPath && r.Method == http.MethodDelete:
+			j.deleted = true
+		case r.URL.Path == jobPath+"/control" && r.Method == http.MethodPost:
+			_ = r.ParseForm()
+			action := r.PostForm.Get("action")
+			if action == "setttl" {
+				action += "=" + r.PostForm.Get("ttl")
+			}
+			j.actions = append(j.actions, action)
+		case r.URL.Path == "/services/saved/searches/Checkout Error Rate" && r.Method == http.MethodGet:
+			_, _ = fmt.Fprint(w, `{"entry":[{"name":"Checkout Error Rate","content":{"search":"index=shop action=checkout | stats count(eval(status>=500)) as errors","dispatch.earliest_time":"-24h","dispatch.latest_time":"now"}}]}`)
+		case r.URL.Path == "/services/saved/searches/Checkout Error Rate/dispatch" && r.Method == http.MethodPost:
+			_ = r.ParseForm()
+			j.dispatched = r.PostForm
+			_, _ = fmt.Fprint(w, `{"sid":"1689673231.191"}`)
+		case r.URL.Path == jobPath+"/results":
+			_, _ = fmt.Fprint(w, `{"results":[{"count":"2566"}]}`)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestJobControl(t *testing.T) {
+
+	job := &mockJob{doneAfter: 1}
+	server := job.server(t)
+	defer server.Close()
+	client := newTestClient(server)
+
+	status, err := GetJobStatus(client, "1689673231.191")
+	if err != nil || !status.IsDone || status.DispatchState != DispatchStateDone || status.ResultCount != 1 || status.RunDuration != 0.42 {
+		t.Fatalf("Unexpected status %+v : %v", status, err)
+	}
+
+	jobs, err := ListJobs(client)
+	if err != nil || len(jobs) != 2 || jobs[1].Sid != "1689673240.192" {
+		t.Fatalf("Unexpected jobs %+v : %v", jobs, err)
+	}
+
+	for _, control := range []func() error{
+		func() error { return PauseJob(client, "1689673231.191") },
+		func() error { return UnpauseJob(client, "1689673231.191") },
+		func() error { return FinalizeJob(client, "1689673231.191") },
+		func() error { return SetTTL(client, "1689673231.191", 10*time.Minute) },
+		func() error { return CancelJob(client, "1689673231.191") },
+		func() error { return DeleteJob(client, "1689673231.191") },
+	} {
+		err = control()
+		if err != nil {
+			t.Fatalf("Got an error : %s", err)
+		}
+	}
+
+	if strings.Join(job.actions, ",") != "pause,unpause,finalize,setttl=600,cancel" || !job.deleted {
+		t.Fatalf("Unexpected actions %v, deleted %v", job.actions, job.deleted)
+	}
+}
+
+// Tests that the requests sharing the client are sent to their own url, the client being used by the handlers, the alerts poller and the state store at once
+func TestJobRequestsShareTheClient(t *testing.T) {
+
+	job := &mockJob{doneAfter: 1}
+	server := job.server(t)
+	defer server.Close()
+	client := newTestClient(server)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			metric, err := GetMetricFromNewJobWithContext(context.Background(), client, &SearchRequest{Params: SearchParams{SearchQuery: "index=main | stats count"}}, MetricField{Name: "count"}, time.Millisecond)
+			if err != nil || metric != 2566 {
+				t.Errorf("Expected 2566 but got %v : %v", metric, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := ListJobs(client)
+			if err != nil {
+				t.Errorf("Got an error : %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if client.Endpoint != "" {
+		t.Fatalf("Expected the endpoint of the client to be left untouched but got %s", client.Endpoint)
+	}
+}
+
+func TestGetMetricFromNewJobWithContext(t *testing.T) {
+
+	job := &mockJob{doneAfter: 3}
+	server := job.server(t)
+	defer server.Close()
+
+	metric, err := GetMetricFromNewJobWithContext(context.Background(), newTestClient(server), &SearchRequest{Params: SearchParams{SearchQuery: "index=main | stats count"}}, MetricField{Name: "count"}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if metric != 2566 || job.statusChecks != 3 {
+		t.Fatalf("Expected 2566 after 3 status checks but got %v after %d", metric, job.statusChecks)
+	}
+	if !job.deleted {
+		t.Fatal("Expected the job to be deleted once its result is read")
+	}
+}
+
+func TestWaitForJobCancelsAbandonedJob(t *testing.T) {
+
+	// the job never finishes
+	job := &mockJob{}
+	server := job.server(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := GetMetricFromNewJobWithContext(ctx, newTestClient(server), &SearchRequest{Params: SearchParams{SearchQuery: "index=main | stats count"}}, MetricField{Name: "count"}, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("Expected the job to be cancelled but got %v", err)
+	}
+	if len(job.actions) != 1 || job.actions[0] != "cancel" || !job.deleted {
+		t.Fatalf("Expected the job to be cancelled and deleted but got %v, deleted %v", job.actions, job.deleted)
+	}
+}
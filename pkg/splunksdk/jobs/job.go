@@ -1,14 +1,16 @@
 package jobs
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
-	"strings"
+	"time"
 
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
-	utils "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
 )
 
 const resutltUri = "results"
@@ -31,30 +33,88 @@ type SearchParams struct {
 	LatestTime string
 }
 
-// Return a metric from a new created job
+// Return a metric from a new created job, the job is deleted once its result is read
 func GetMetricFromNewJob(client *splunk.SplunkClient, spRequest *SearchRequest) (float64, error) {
 
 	sid, err := CreateJob(client, spRequest, jobsPathv2)
 	if err != nil {
 		return -1, fmt.Errorf("error while creating the job : %w", err)
 	}
+	defer func() { _ = DeleteJob(client, sid) }()
 
 	res, err := RetrieveJobResult(client, sid)
 
 	if err != nil {
 		return -1, fmt.Errorf("error while handling the results. Error message : %w", err)
 	}
+	return metricFromResult(res, MetricField{})
+}
+
+// Return a metric from a new job run in the background, the job is cancelled if the context is done before it finishes
+//
+//	the state of the job is checked at every interval and the job is deleted once its result is read,
+//	the metric is read from the field of the result, see MetricField
+func GetMetricFromNewJobWithContext(ctx context.Context, client *splunk.SplunkClient, spRequest *SearchRequest, field MetricField, interval time.Duration) (float64, error) {
+
+	spRequest.Params.ExecMode = "normal"
+	sid, err := CreateJob(client, spRequest, jobsPathv2)
+	if err != nil {
+		return -1, fmt.Errorf("error while creating the job : %w", err)
+	}
+	defer func() { _ = DeleteJob(client, sid) }()
+
+	_, err = WaitForJob(ctx, client, sid, interval)
+	if err != nil {
+		return -1, fmt.Errorf("error while waiting for the job : %w", err)
+	}
+
+	res, err := RetrieveJobResult(client, sid)
+	if err != nil {
+		return -1, fmt.Errorf("error while handling the results. Error message : %w", err)
+	}
+	return metricFromResult(res, field)
+}
+
+// ErrNoResult is returned when the search of a metric returns no result
+var ErrNoResult = errors.New("no result found")
+
+// MetricField is the field of the result the metric is read from
+type MetricField struct {
+	// the single non-empty field of the result is read if empty
+	Name string
+	// the name is guessed from the query, the single non-empty field of the result is read when the result has no such field
+	Inferred bool
+}
+
+// return the value of the field of the single result, or the value of its single field if the field has no name
+func metricFromResult(res []map[string]string, field MetricField) (float64, error) {
+	var err error
 	// if the result is not a metric
 	if len(res) != 1 {
 		if len(res) == 0 {
-			err = fmt.Errorf("no result found")
+			err = ErrNoResult
 		}
 		return -1, fmt.Errorf("result is not a metric. Error message : %w", err)
 	}
+	// an aggregation over no event returns a row without field or with an empty field, splunk leaving out the null fields
 	var metrics []string
-
-	for _, v := range res[0] {
-		metrics = append(metrics, v)
+	if value := res[0][field.Name]; field.Name != "" && value != "" {
+		metrics = append(metrics, value)
+	} else if field.Name == "" || field.Inferred {
+		for _, v := range res[0] {
+			if v != "" {
+				metrics = append(metrics, v)
+			}
+		}
+	}
+	if len(metrics) == 0 {
+		return -1, fmt.Errorf("result is not a metric. Error message : %w", ErrNoResult)
+	}
+	if len(metrics) > 1 {
+		if field.Name != "" {
+			return -1, fmt.Errorf("result is not a metric, it has several fields and none is the result field %s", field.Name)
+		}
+		return -1, fmt.Errorf("result is not a metric, it has several fields and no result field is given")
 	}
 	metric, err := strconv.ParseFloat(metrics[0], 64)
 	if err != nil {
@@ -68,29 +128,25 @@ func GetMetricFromNewJob(client *splunk.SplunkClient, spRequest *SearchRequest)
 func CreateJob(client *splunk.SplunkClient, spRequest *SearchRequest, service string) (string, error) {
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, jobsPathv2)
+	endpoint := client.BuildEndpoint(service)
 
-	resp, err := PostJob(client, spRequest)
+	resp, err := PostJob(client, endpoint, spRequest)
 
 	if err != nil {
 		return "", fmt.Errorf("error while making the post request : %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	// handle error
-	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return "", fmt.Errorf("http error :  %s", status)
-		default:
-			return "", fmt.Errorf("http error :  %s", resp.Status)
-		}
-	}
+	defer resp.Body.Close()
 
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("error while getting the body of the post request : %w", err)
 	}
+	// handle error
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
+	if err != nil {
+		return "", fmt.Errorf("http error : %w", err)
+	}
 
 	// create the new endpoint for the post request
 	var sid string
@@ -102,52 +158,53 @@ func CreateJob(client *splunk.SplunkClient, spRequest *SearchRequest, service st
 	return sid, nil
 }
 
-// return the result of a job get by its SID
+// return the result of a job get by its SID, the values of the multivalue fields are joined with a comma
 func RetrieveJobResult(client *splunk.SplunkClient, sid string) ([]map[string]string, error) {
 
-	newEndpoint := client.Endpoint + sid
-	// check if the endpoint is correctly formed
-	if !strings.HasSuffix(newEndpoint, "/") {
-		newEndpoint += "/"
+	results, err := RetrieveJobResults(client, sid)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]string, 0, len(results.Rows))
+	for _, row := range results.Rows {
+		rows = append(rows, row.Strings())
 	}
+	return rows, nil
+}
+
+// return the results of a job get by its SID with their metadata
+func RetrieveJobResults(client *splunk.SplunkClient, sid string) (*Results, error) {
 
 	// the endpoint where to find the corresponding job
-	client.Endpoint = newEndpoint + resutltUri
+	endpoint := client.BuildEndpoint(jobsPathv2 + url.PathEscape(sid) + "/" + resutltUri)
 
 	// make the get request
-	getResp, err := GetJob(client)
+	getResp, err := GetJob(client, endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("error while making the get request : %w", err)
 	}
 
+	defer getResp.Body.Close()
+
 	// get the body of the response
 	getBody, err := io.ReadAll(getResp.Body)
-	// handle error
-	if !strings.HasPrefix(strconv.Itoa(getResp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(getBody)
-		switch err {
-		case nil:
-			return nil, fmt.Errorf("http error :  %s", status)
-		default:
-			return nil, fmt.Errorf("http error :  %s", getResp.Status)
-		}
-	}
 	if err != nil {
 		return nil, fmt.Errorf("error while getting the body of the get request : %w", err)
 	}
-
-	// only get the result section of the response
-	type Response struct {
-		Results []map[string]string `json:"results"`
+	// handle error
+	err = splunk.CheckHttpResponse(getResp, getBody, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http error : %w", err)
 	}
 
-	results := Response{}
-	errUmarshall := json.Unmarshal([]byte(getBody), &results)
+	results := &Results{}
+	errUmarshall := json.Unmarshal(getBody, results)
 
 	if errUmarshall != nil {
 		return nil, errUmarshall
 	}
-	return results.Results, nil
+	return results, nil
 }
 
 // Return the sid from the body of the given response
@@ -8,24 +8,26 @@ import (
 	utils "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
 )
 
-func PostJob(client *splunk.SplunkClient, spRequest *SearchRequest) (*http.Response, error) {
+func PostJob(client *splunk.SplunkClient, endpoint string, spRequest *SearchRequest) (*http.Response, error) {
 
-	return HttpJobRequest(client, http.MethodPost, spRequest)
+	return HttpJobRequest(client, http.MethodPost, endpoint, spRequest)
 }
 
-func GetJob(client *splunk.SplunkClient) (*http.Response, error) {
+func GetJob(client *splunk.SplunkClient, endpoint string) (*http.Response, error) {
 
-	return HttpJobRequest(client, http.MethodGet, nil)
+	return HttpJobRequest(client, http.MethodGet, endpoint, nil)
 }
 
-func HttpJobRequest(client *splunk.SplunkClient, method string, spRequest *SearchRequest) (*http.Response, error) {
+func HttpJobRequest(client *splunk.SplunkClient, method string, endpoint string, spRequest *SearchRequest) (*http.Response, error) {
 
 	if spRequest == nil {
 		spRequest = &SearchRequest{}
 	}
 
 	spRequest.Params.OutputMode = "json"
-	spRequest.Params.ExecMode = "blocking"
+	if spRequest.Params.ExecMode == "" {
+		spRequest.Params.ExecMode = "blocking"
+	}
 
 	// parameters of the request
 	params := url.Values{}
@@ -42,5 +44,5 @@ func HttpJobRequest(client *splunk.SplunkClient, method string, spRequest *Searc
 		}
 	}
 
-	return splunk.MakeHttpRequest(client, method, spRequest.Headers, params)
+	return splunk.MakeHttpRequestToEndpoint(client, method, endpoint, spRequest.Headers, params.Encode())
 }
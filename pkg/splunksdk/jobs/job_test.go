@@ -6,7 +6,6 @@ import (
 	"time"
 
 	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
-	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
 	splunkTest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
 
 	"github.com/joho/godotenv"
@@ -89,8 +88,6 @@ func TestCreateJob(t *testing.T) {
 		true,
 	)
 
-	utils.CreateEndpoint(client, splunkTest.JobsPathv2)
-
 	sid, err := CreateJob(client, &spReq, splunkTest.JobsPathv2)
 
 	if err != nil {
@@ -122,7 +119,6 @@ func TestRetrieveJobResult(t *testing.T) {
 		splunkTest.GetTestToken(),
 		true,
 	)
-	utils.CreateEndpoint(client, splunkTest.JobsPathv2)
 	results, err := RetrieveJobResult(client, "1689673231.191")
 
 	if err != nil {
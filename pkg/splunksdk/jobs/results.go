@@ -0,0 +1,317 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// format of the _time field of the results
+const timeFormat = "2006-01-02T15:04:05.000-07:00"
+
+// Results are the results of a search with their metadata
+type Results struct {
+	// true if the search is still running and the results are partial
+	Preview bool `json:"preview"`
+	// offset of the first row in the results of the job
+	InitOffset int       `json:"init_offset"`
+	Messages   []Message `json:"messages"`
+	// fields of the results in the order of the search
+	Fields []Field `json:"fields"`
+	Rows   []Row   `json:"results"`
+}
+
+// Message is an informational, warning or error message of a search
+type Message struct {
+	// INFO, WARN, ERROR or FATAL
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Field describes a field of the results
+type Field struct {
+	Name string `json:"name"`
+}
+
+// Row is a result of a search
+type Row map[string]Value
+
+// Value is the value of a field of a row: a string, a number or the values of a multivalue field
+type Value struct {
+	// values of the field, a single one unless the field is multivalue
+	Values []string
+	// true if splunk returned a json number
+	IsNumber bool
+}
+
+// the fields may be given by name only
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		f.Name = name
+		return nil
+	}
+
+	type field Field
+	return json.Unmarshal(data, (*field)(f))
+}
+
+func (v *Value) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	*v = Value{}
+
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		var values []json.RawMessage
+		err := json.Unmarshal(data, &values)
+		if err != nil {
+			return err
+		}
+		for _, raw := range values {
+			var value Value
+			err = value.UnmarshalJSON(raw)
+			if err != nil {
+				return err
+			}
+			v.Values = append(v.Values, value.Values...)
+		}
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var value string
+		err := json.Unmarshal(data, &value)
+		if err != nil {
+			return err
+		}
+		v.Values = []string{value}
+		return nil
+	default:
+		var number json.Number
+		err := json.Unmarshal(data, &number)
+		if err != nil {
+			return fmt.Errorf("unsupported value %s", data)
+		}
+		v.Values = []string{number.String()}
+		v.IsNumber = true
+		return nil
+	}
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch {
+	case len(v.Values) == 0:
+		return []byte("null"), nil
+	case len(v.Values) > 1:
+		return json.Marshal(v.Values)
+	case v.IsNumber:
+		return []byte(v.Values[0]), nil
+	default:
+		return json.Marshal(v.Values[0])
+	}
+}
+
+// true if the field has several values
+func (v Value) IsMultivalue() bool {
+	return len(v.Values) > 1
+}
+
+// return the value, the values of a multivalue field are joined with a comma
+func (v Value) String() string {
+	return strings.Join(v.Values, ",")
+}
+
+// return the value as a number, an error if it is not a single number
+func (v Value) Float() (float64, error) {
+	if len(v.Values) != 1 {
+		return 0, fmt.Errorf("expected a single value but got %d", len(v.Values))
+	}
+	return strconv.ParseFloat(v.Values[0], 64)
+}
+
+// return the value of the field as a string, see Value.String
+func (r Row) String(field string) string {
+	return r[field].String()
+}
+
+// return the time of the row, read from its _time field
+func (r Row) Time() (time.Time, error) {
+	value, ok := r["_time"]
+	if !ok {
+		return time.Time{}, fmt.Errorf("no _time field in the result")
+	}
+	return parseTime(value.String())
+}
+
+// return the row as strings, the values of the multivalue fields being joined with a comma
+func (r Row) Strings() map[string]string {
+	values := make(map[string]string, len(r))
+	for field, value := range r {
+		values[field] = value.String()
+	}
+	return values
+}
+
+// return the names of the fields in the order of the search
+func (r *Results) FieldNames() []string {
+	names := make([]string, 0, len(r.Fields))
+	for _, field := range r.Fields {
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+// return the errors reported by splunk for the search, nil if there are none
+func (r *Results) Err() error {
+	var texts []string
+	for _, message := range r.Messages {
+		if message.Type == "ERROR" || message.Type == "FATAL" {
+			texts = append(texts, message.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("search error : %s", strings.Join(texts, "; "))
+}
+
+// Decodes the rows into out, a pointer to a slice of structs, see Row.Decode
+func (r *Results) Decode(out interface{}) error {
+
+	slice := reflect.ValueOf(out)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice but got %T", out)
+	}
+	slice = slice.Elem()
+
+	decoded := reflect.MakeSlice(slice.Type(), 0, len(r.Rows))
+	for i, row := range r.Rows {
+		item := reflect.New(slice.Type().Elem())
+		err := row.Decode(item.Interface())
+		if err != nil {
+			return fmt.Errorf("row %d : %w", i, err)
+		}
+		decoded = reflect.Append(decoded, item.Elem())
+	}
+	slice.Set(decoded)
+	return nil
+}
+
+// Decodes the row into out, a pointer to a struct
+//
+//	the field of the row is given by the splunk tag of the struct field, its json tag otherwise, or its name
+//	struct fields can be strings, numbers, booleans, slices of strings (multivalue fields) or time.Time (_time)
+func (r Row) Decode(out interface{}) error {
+
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct but got %T", out)
+	}
+	target = target.Elem()
+
+	for i := 0; i < target.NumField(); i++ {
+		structField := target.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		name := fieldName(structField)
+		if name == "-" {
+			continue
+		}
+		value, ok := r[name]
+		if !ok || len(value.Values) == 0 {
+			continue
+		}
+
+		err := setField(target.Field(i), value)
+		if err != nil {
+			return fmt.Errorf("field %s : %w", name, err)
+		}
+	}
+	return nil
+}
+
+// return the name of the field of the row decoded into the struct field
+func fieldName(structField reflect.StructField) string {
+	for _, tag := range []string{"splunk", "json"} {
+		name, _, _ := strings.Cut(structField.Tag.Get(tag), ",")
+		if name != "" {
+			return name
+		}
+	}
+	return structField.Name
+}
+
+// set the value to the struct field depending on its type
+func setField(field reflect.Value, value Value) error {
+
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		t, err := parseTime(value.String())
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value.String())
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		values := reflect.MakeSlice(field.Type(), len(value.Values), len(value.Values))
+		for i, v := range value.Values {
+			values.Index(i).SetString(v)
+		}
+		field.Set(values)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value.String())
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := value.Float()
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value.String(), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value.String(), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// parse a _time value, either formatted or an epoch time in seconds
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(timeFormat, value)
+	if err == nil {
+		return t, nil
+	}
+
+	epoch, errEpoch := strconv.ParseFloat(value, 64)
+	if errEpoch != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s : %w", value, err)
+	}
+	seconds := int64(epoch)
+	return time.Unix(seconds, int64((epoch-float64(seconds))*1e9)), nil
+}
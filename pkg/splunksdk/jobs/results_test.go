@@ -0,0 +1,195 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	splunkTest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+const resultsResponse = `{
+	"preview": false,
+	"init_offset": 10,
+	"messages": [{"type": "WARN", "text": "Search results might be incomplete."}],
+	"fields": [{"name": "_time"}, {"name": "host"}, {"name": "count"}, {"name": "avg"}],
+	"results": [
+		{"_time": "2023-07-18T10:00:00.000+02:00", "host": ["web-1", "web-2"], "count": 12, "avg": "0.5"},
+		{"_time": "2023-07-18T10:05:00.000+02:00", "host": "web-3", "count": "3", "avg": null}
+	]
+}`
+
+type hostStats struct {
+	Time  time.Time `splunk:"_time"`
+	Hosts []string  `splunk:"host"`
+	Count int       `json:"count"`
+	Avg   float64   `splunk:"avg"`
+}
+
+func TestResultsUnmarshal(t *testing.T) {
+
+	var results Results
+	err := json.Unmarshal([]byte(resultsResponse), &results)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	if results.InitOffset != 10 || len(results.Messages) != 1 || results.Err() != nil {
+		t.Fatalf("Unexpected metadata %+v", results)
+	}
+	if names := results.FieldNames(); len(names) != 4 || names[0] != "_time" || names[3] != "avg" {
+		t.Fatalf("Unexpected fields %v", names)
+	}
+
+	first := results.Rows[0]
+	if !first["host"].IsMultivalue() || first.String("host") != "web-1,web-2" {
+		t.Fatalf("Expected a multivalue host but got %v", first["host"])
+	}
+	if count, err := first["count"].Float(); err != nil || count != 12 || !first["count"].IsNumber {
+		t.Fatalf("Expected the number 12 but got %v : %v", first["count"], err)
+	}
+	if ts, err := first.Time(); err != nil || ts.UTC().Hour() != 8 {
+		t.Fatalf("Unexpected time %v : %v", ts, err)
+	}
+	if len(results.Rows[1]["avg"].Values) != 0 {
+		t.Fatalf("Expected a null avg but got %v", results.Rows[1]["avg"])
+	}
+
+	// the values are written back as splunk returned them
+	content, err := json.Marshal(first)
+	if err != nil || string(content) != `{"_time":"2023-07-18T10:00:00.000+02:00","avg":"0.5","count":12,"host":["web-1","web-2"]}` {
+		t.Fatalf("Unexpected json %s : %v", content, err)
+	}
+}
+
+func TestResultsDecode(t *testing.T) {
+
+	var results Results
+	err := json.Unmarshal([]byte(resultsResponse), &results)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	var stats []hostStats
+	err = results.Decode(&stats)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	if len(stats) != 2 || len(stats[0].Hosts) != 2 || stats[0].Count != 12 || stats[0].Avg != 0.5 || stats[0].Time.IsZero() {
+		t.Fatalf("Unexpected first row %+v", stats)
+	}
+	if stats[1].Hosts[0] != "web-3" || stats[1].Count != 3 || stats[1].Avg != 0 {
+		t.Fatalf("Unexpected second row %+v", stats[1])
+	}
+
+	var wrongType []struct {
+		Count time.Duration `splunk:"host"`
+	}
+	if results.Decode(&wrongType) == nil {
+		t.Fatal("Expected an error when decoding a host into a number")
+	}
+}
+
+func TestResultsErr(t *testing.T) {
+	results := Results{Messages: []Message{{Type: "INFO", Text: "ok"}, {Type: "FATAL", Text: "Unknown search command 'stat'."}}}
+	if results.Err() == nil {
+		t.Fatal("Expected the fatal message to be reported")
+	}
+}
+
+func TestRetrieveJobResultMultivalue(t *testing.T) {
+
+	server := splunkTest.MockRequest(resultsResponse, true)
+	defer server.Close()
+
+	client := newTestClient(server)
+	results, err := RetrieveJobResult(client, "1689673231.191")
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	if len(results) != 2 || results[0]["host"] != "web-1,web-2" || results[0]["count"] != "12" {
+		t.Fatalf("Unexpected results %v", results)
+	}
+}
+
+// Tests that a search without result is reported with ErrNoResult
+func TestMetricFromResultWithoutResult(t *testing.T) {
+	_, err := metricFromResult(nil, MetricField{})
+	if !errors.Is(err, ErrNoResult) {
+		t.Fatalf("Expected ErrNoResult but got %v", err)
+	}
+
+	_, err = metricFromResult([]map[string]string{{"count": "1"}, {"count": "2"}}, MetricField{})
+	if err == nil || errors.Is(err, ErrNoResult) {
+		t.Fatalf("Expected an error other than ErrNoResult for several results but got %v", err)
+	}
+}
+
+// Tests that the aggregations over no event, returning an empty row or a null value, are reported with ErrNoResult
+func TestMetricFromResultWithEmptyRow(t *testing.T) {
+	for _, payload := range []string{`{"results":[{}]}`, `{"results":[{"value":null}]}`, `{"results":[{"value":""}]}`} {
+		server := splunkTest.MockRequest(payload, true)
+
+		client := newTestClient(server)
+		results, err := RetrieveJobResult(client, "1689673231.191")
+		server.Close()
+		if err != nil {
+			t.Fatalf("Got an error for %s : %s", payload, err)
+		}
+
+		_, err = metricFromResult(results, MetricField{})
+		if !errors.Is(err, ErrNoResult) {
+			t.Errorf("Expected ErrNoResult for %s but got %v", payload, err)
+		}
+	}
+}
+
+// Tests that the value of a result with several fields is read from its result field
+func TestMetricFromResultWithSeveralFields(t *testing.T) {
+	res := []map[string]string{{"errors": "5", "total": "200", "rate": "0.025"}}
+
+	for i := 0; i < 10; i++ {
+		metric, err := metricFromResult(res, MetricField{Name: "rate"})
+		if err != nil || metric != 0.025 {
+			t.Fatalf("Expected the value of the rate field but got %v : %v", metric, err)
+		}
+	}
+
+	_, err := metricFromResult(res, MetricField{})
+	if err == nil {
+		t.Fatal("Expected an error for a result with several fields and no result field")
+	}
+
+	// the null fields are left out of the results by splunk
+	_, err = metricFromResult([]map[string]string{{"total": "0"}}, MetricField{Name: "rate"})
+	if !errors.Is(err, ErrNoResult) {
+		t.Fatalf("Expected ErrNoResult for a result without its result field but got %v", err)
+	}
+}
+
+// Tests that a field guessed from the query falls back to the single field of a result named otherwise
+func TestMetricFromResultWithInferredField(t *testing.T) {
+	metric, err := metricFromResult([]map[string]string{{"theRequest": "42"}}, MetricField{Name: "count", Inferred: true})
+	if err != nil || metric != 42 {
+		t.Fatalf("Expected the value of the single field but got %v : %v", metric, err)
+	}
+
+	metric, err = metricFromResult([]map[string]string{{"errors": "5", "count": "200"}}, MetricField{Name: "count", Inferred: true})
+	if err != nil || metric != 200 {
+		t.Fatalf("Expected the value of the inferred field but got %v : %v", metric, err)
+	}
+
+	_, err = metricFromResult([]map[string]string{{"errors": "5", "total": "200"}}, MetricField{Name: "count", Inferred: true})
+	if err == nil || errors.Is(err, ErrNoResult) {
+		t.Fatalf("Expected an error other than ErrNoResult for several fields without the inferred one but got %v", err)
+	}
+
+	// a field set in the sli file is not replaced
+	_, err = metricFromResult([]map[string]string{{"theRequest": "42"}}, MetricField{Name: "count"})
+	if !errors.Is(err, ErrNoResult) {
+		t.Fatalf("Expected ErrNoResult for a result without the configured field but got %v", err)
+	}
+}
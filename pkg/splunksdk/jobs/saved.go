@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+const savedSearchesPath = "services/saved/searches/"
+
+// SavedSearch is a saved search (report or alert) of splunk
+type SavedSearch struct {
+	Name string
+	// query of the saved search, without the search keyword
+	Search string
+	// time range of the saved search when it is dispatched without overrides
+	EarliestTime string
+	LatestTime   string
+}
+
+// content of the entry of a saved search
+type savedSearchEntry struct {
+	Name    string `json:"name"`
+	Content struct {
+		Search       string `json:"search"`
+		EarliestTime string `json:"dispatch.earliest_time"`
+		LatestTime   string `json:"dispatch.latest_time"`
+	} `json:"content"`
+}
+
+// path of a saved search, its name is escaped
+func savedSearchPath(name string) string {
+	return savedSearchesPath + url.PathEscape(name)
+}
+
+// GetSavedSearch returns the saved search with the given name
+func GetSavedSearch(client *splunk.SplunkClient, name string) (*SavedSearch, error) {
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	body, err := jobRequest(client, http.MethodGet, savedSearchPath(name), params)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the saved search %s : %w", name, err)
+	}
+
+	var response struct {
+		Entry []savedSearchEntry `json:"entry"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding the saved search %s : %w", name, err)
+	}
+	if len(response.Entry) == 0 {
+		return nil, fmt.Errorf("no saved search %s", name)
+	}
+
+	entry := response.Entry[0]
+	return &SavedSearch{
+		Name:         entry.Name,
+		Search:       entry.Content.Search,
+		EarliestTime: entry.Content.EarliestTime,
+		LatestTime:   entry.Content.LatestTime,
+	}, nil
+}
+
+// DispatchSavedSearch runs the saved search with the given name and returns the SID of its job
+//
+//	the earliest and latest times override the time range of the saved search when set and its actions are not triggered
+func DispatchSavedSearch(client *splunk.SplunkClient, name string, earliestTime string, latestTime string) (string, error) {
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	params.Add("trigger_actions", "0")
+	if earliestTime != "" {
+		params.Add("dispatch.earliest_time", earliestTime)
+	}
+	if latestTime != "" {
+		params.Add("dispatch.latest_time", latestTime)
+	}
+
+	body, err := jobRequest(client, http.MethodPost, savedSearchPath(name)+"/dispatch", params)
+	if err != nil {
+		return "", fmt.Errorf("error while dispatching the saved search %s : %w", name, err)
+	}
+
+	sid, err := getSID(body)
+	if err != nil {
+		return "", fmt.Errorf("error : %w", err)
+	}
+	return sid, nil
+}
+
+// Return a metric from a new job of the saved search, the job is cancelled if the context is done before it finishes
+//
+//	the state of the job is checked at every interval and the job is deleted once its result is read,
+//	the metric is read from the resultField of the result, or from its single field if resultField is empty
+func GetMetricFromSavedSearchWithContext(ctx context.Context, client *splunk.SplunkClient, name string, earliestTime string, latestTime string, resultField string, interval time.Duration) (float64, error) {
+
+	sid, err := DispatchSavedSearch(client, name, earliestTime, latestTime)
+	if err != nil {
+		return -1, err
+	}
+	defer func() { _ = DeleteJob(client, sid) }()
+
+	_, err = WaitForJob(ctx, client, sid, interval)
+	if err != nil {
+		return -1, fmt.Errorf("error while waiting for the job : %w", err)
+	}
+
+	res, err := RetrieveJobResult(client, sid)
+	if err != nil {
+		return -1, fmt.Errorf("error while handling the results. Error message : %w", err)
+	}
+	return metricFromResult(res, MetricField{Name: resultField})
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetSavedSearch(t *testing.T) {
+
+	job := &mockJob{}
+	server := job.server(t)
+	defer server.Close()
+	client := newTestClient(server)
+
+	savedSearch, err := GetSavedSearch(client, "Checkout Error Rate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if savedSearch.Name != "Checkout Error Rate" || savedSearch.Search != "index=shop action=checkout | stats count(eval(status>=500)) as errors" || savedSearch.EarliestTime != "-24h" {
+		t.Fatalf("Unexpected saved search %+v", savedSearch)
+	}
+}
+
+// Tests that the saved search is dispatched with the given time range and that its metric is read
+func TestGetMetricFromSavedSearch(t *testing.T) {
+
+	job := &mockJob{doneAfter: 2}
+	server := job.server(t)
+	defer server.Close()
+	client := newTestClient(server)
+
+	metric, err := GetMetricFromSavedSearchWithContext(context.Background(), client, "Checkout Error Rate", "1657024000", "1657024300", "", time.Millisecond)
+	if err != nil || metric != 2566 {
+		t.Fatalf("Expected the metric 2566 but got %v : %v", metric, err)
+	}
+
+	if job.dispatched.Get("dispatch.earliest_time") != "1657024000" || job.dispatched.Get("dispatch.latest_time") != "1657024300" || job.dispatched.Get("trigger_actions") != "0" {
+		t.Fatalf("Unexpected dispatch parameters %v", job.dispatched)
+	}
+	if !job.deleted {
+		t.Fatal("Expected the job to be deleted once its result is read")
+	}
+}
@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	utils "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+const exportPath = "services/search/v2/jobs/export"
+
+// number of rows of a page when none is given
+const defaultPageSize = 1000
+
+// ResultIterator yields the rows of a search one at a time, so only the current row or page is held in memory
+//
+//	for it.Next() {
+//		row := it.Row()
+//	}
+//	if it.Err() != nil { ... }
+type ResultIterator struct {
+	next  func() (Row, error)
+	close func() error
+
+	row    Row
+	err    error
+	done   bool
+	closed bool
+}
+
+// move to the next row, false when there are no more rows or an error occurred
+func (it *ResultIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	row, err := it.next()
+	if err != nil {
+		it.done = true
+		it.row = nil
+		if err != io.EOF {
+			it.err = err
+		}
+		_ = it.Close()
+		return false
+	}
+
+	it.row = row
+	return true
+}
+
+// return the current row
+func (it *ResultIterator) Row() Row {
+	return it.row
+}
+
+// return the error which stopped the iteration, nil if all the rows were read
+func (it *ResultIterator) Err() error {
+	return it.err
+}
+
+// release the response being read, to call when the iteration is stopped before the end
+func (it *ResultIterator) Close() error {
+	it.done = true
+	if it.closed || it.close == nil {
+		return nil
+	}
+	it.closed = true
+	return it.close()
+}
+
+// a line of the response of the export endpoint
+type exportLine struct {
+	Preview  bool      `json:"preview"`
+	Result   Row       `json:"result"`
+	Messages []Message `json:"messages"`
+}
+
+// Runs the search with the export endpoint and streams its results as they are produced, without creating a job to poll
+//
+//	the previews of the reporting searches are skipped, only the final results are returned
+//	the timeout of the http client applies to the whole iteration
+func ExportSearch(client *splunk.SplunkClient, spRequest *SearchRequest) (*ResultIterator, error) {
+
+	endpoint := client.BuildEndpoint(exportPath)
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	params.Add("search", utils.ValidateSearchQuery(spRequest.Params.SearchQuery))
+	if spRequest.Params.EarliestTime != "" {
+		params.Add("earliest_time", spRequest.Params.EarliestTime)
+	}
+	if spRequest.Params.LatestTime != "" {
+		params.Add("latest_time", spRequest.Params.LatestTime)
+	}
+
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	for name, value := range spRequest.Headers {
+		headers[name] = value
+	}
+
+	resp, err := splunk.MakeHttpRequestToEndpoint(client, http.MethodPost, endpoint, headers, params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error while making the post request : %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error while getting the body of the post request : %w", err)
+		}
+		return nil, fmt.Errorf("http error : %w", splunk.CheckHttpResponse(resp, body, endpoint))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	next := func() (Row, error) {
+		for {
+			var line exportLine
+			err := decoder.Decode(&line)
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not map the exported result to datastructure: %w", err)
+			}
+
+			for _, message := range line.Messages {
+				if message.Type == "ERROR" || message.Type == "FATAL" {
+					return nil, fmt.Errorf("search error : %s", message.Text)
+				}
+			}
+			if line.Preview || line.Result == nil {
+				continue
+			}
+			return line.Result, nil
+		}
+	}
+
+	return &ResultIterator{next: next, close: resp.Body.Close}, nil
+}
+
+// Reads the results of a finished job page by page, pageSize rows per request
+func PaginateJobResults(client *splunk.SplunkClient, sid string, pageSize int) *ResultIterator {
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	offset := 0
+	lastPage := false
+	var page []Row
+
+	next := func() (Row, error) {
+		for len(page) == 0 {
+			if lastPage {
+				return nil, io.EOF
+			}
+
+			var err error
+			page, err = getResultsPage(client, sid, offset, pageSize)
+			if err != nil {
+				return nil, err
+			}
+			offset += len(page)
+			lastPage = len(page) < pageSize
+		}
+
+		row := page[0]
+		page = page[1:]
+		return row, nil
+	}
+
+	return &ResultIterator{next: next}
+}
+
+// return the rows of the job from offset, at most count
+func getResultsPage(client *splunk.SplunkClient, sid string, offset int, count int) ([]Row, error) {
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	params.Add("offset", strconv.Itoa(offset))
+	params.Add("count", strconv.Itoa(count))
+
+	body, err := jobRequest(client, http.MethodGet, jobsPathv2+url.PathEscape(sid)+"/"+resutltUri, params)
+	if err != nil {
+		return nil, fmt.Errorf("results page : %w", err)
+	}
+
+	var results Results
+	err = json.Unmarshal(body, &results)
+	if err != nil {
+		return nil, fmt.Errorf("could not map the results to datastructure: %w", err)
+	}
+	return results.Rows, results.Err()
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	splunkTest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+func newTestClient(server *httptest.Server) *splunk.SplunkClient {
+	return splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		true,
+	)
+}
+
+func TestExportSearch(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path != "/"+exportPath || r.PostForm.Get("search") != "search index=main | stats count by host" || r.PostForm.Get("earliest_time") != "-1h" {
+			t.Errorf("Unexpected request %s %v", r.URL.Path, r.PostForm)
+		}
+		_, _ = fmt.Fprintln(w, `{"preview":true,"offset":0,"result":{"host":"a","count":"1"}}`)
+		_, _ = fmt.Fprintln(w, `{"preview":false,"offset":0,"result":{"host":"a","count":"2"}}`)
+		_, _ = fmt.Fprintln(w, `{"preview":false,"offset":1,"lastrow":true,"result":{"host":["b","c"],"count":"3"}}`)
+	}))
+	defer server.Close()
+
+	it, err := ExportSearch(newTestClient(server), &SearchRequest{Params: SearchParams{SearchQuery: "index=main | stats count by host", EarliestTime: "-1h"}})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer it.Close()
+
+	var rows []Row
+	for it.Next() {
+		rows = append(rows, it.Row())
+	}
+	if it.Err() != nil {
+		t.Fatalf("Got an error : %s", it.Err())
+	}
+
+	if len(rows) != 2 || rows[0].String("count") != "2" || rows[1].String("host") != "b,c" {
+		t.Fatalf("Expected the 2 final rows but got %v", rows)
+	}
+}
+
+func TestExportSearchError(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, `{"preview":false,"offset":0,"result":{"count":"2"}}`)
+		_, _ = fmt.Fprintln(w, `{"messages":[{"type":"FATAL","text":"Unknown search command 'stat'."}]}`)
+	}))
+	defer server.Close()
+
+	it, err := ExportSearch(newTestClient(server), &SearchRequest{Params: SearchParams{SearchQuery: "index=main | stat count"}})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	rows := 0
+	for it.Next() {
+		rows++
+	}
+	if rows != 1 || it.Err() == nil || !strings.Contains(it.Err().Error(), "Unknown search command") {
+		t.Fatalf("Expected the search error after the first row but got %v rows and %v", rows, it.Err())
+	}
+}
+
+func TestPaginateJobResults(t *testing.T) {
+
+	const total = 25
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/"+splunkTest.JobsPathv2+"1689673231.191/results" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+
+		rows := []string{}
+		for i := offset; i < total && i < offset+count; i++ {
+			rows = append(rows, fmt.Sprintf(`{"index":%d}`, i))
+		}
+		_, _ = fmt.Fprintf(w, `{"results":[%s]}`, strings.Join(rows, ","))
+	}))
+	defer server.Close()
+
+	it := PaginateJobResults(newTestClient(server), "1689673231.191", 10)
+	defer it.Close()
+
+	rows := 0
+	for it.Next() {
+		if index, _ := it.Row()["index"].Float(); index != float64(rows) {
+			t.Fatalf("Expected the row %d but got %v", rows, it.Row())
+		}
+		rows++
+	}
+	if it.Err() != nil {
+		t.Fatalf("Got an error : %s", it.Err())
+	}
+	if rows != total || requests != 3 {
+		t.Fatalf("Expected %d rows in 3 pages but got %d rows in %d requests", total, rows, requests)
+	}
+}
+
+func TestExportSearchGeneratingCommand(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		// the generating commands must not be prefixed by search
+		if r.PostForm.Get("search") != "| tstats count where index=main" {
+			t.Errorf("Unexpected search %s", r.PostForm.Get("search"))
+		}
+		_, _ = fmt.Fprintln(w, `{"preview":false,"offset":0,"lastrow":true,"result":{"count":"42"}}`)
+	}))
+	defer server.Close()
+
+	it, err := ExportSearch(newTestClient(server), &SearchRequest{Params: SearchParams{SearchQuery: " | tstats count where index=main"}})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer it.Close()
+
+	if !it.Next() || it.Row().String("count") != "42" {
+		t.Fatalf("Expected the count but got %v : %v", it.Row(), it.Err())
+	}
+}
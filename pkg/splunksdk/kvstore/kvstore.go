@@ -0,0 +1,292 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+const collectionsConfigPath = "servicesNS/%s/%s/storage/collections/config"
+const collectionsDataPath = "servicesNS/%s/%s/storage/collections/data"
+
+// Namespace is the owner and the app the collections belong to
+type Namespace struct {
+	// nobody for the collections shared by all the users
+	Owner string
+	App   string
+}
+
+// DefaultNamespace is the namespace of the collections shared in the search app
+var DefaultNamespace = Namespace{Owner: "nobody", App: "search"}
+
+// Query selects records of a collection
+type Query struct {
+	// mongodb-like filter, e.g. {"project": "fulltour", "score": {"$gt": 90}}
+	Filter map[string]interface{}
+	// fields returned, all if empty
+	Fields []string
+	// e.g. "score:-1" to sort by decreasing score
+	Sort  string
+	Limit int
+	Skip  int
+}
+
+type collectionList struct {
+	Entry []struct {
+		Name string `json:"name"`
+	} `json:"entry"`
+}
+
+type recordKey struct {
+	Key string `json:"_key"`
+}
+
+// return the path of the configuration of the collections of the namespace
+func configPath(ns Namespace) string {
+	return fmt.Sprintf(collectionsConfigPath, url.PathEscape(ns.Owner), url.PathEscape(ns.App))
+}
+
+// return the path of the records of the collection
+func dataPath(ns Namespace, collection string) string {
+	return fmt.Sprintf(collectionsDataPath, url.PathEscape(ns.Owner), url.PathEscape(ns.App)) + "/" + url.PathEscape(collection)
+}
+
+// Creates a collection, fields maps the name of the typed fields to their type (string, number, bool, time...)
+func CreateCollection(client *splunk.SplunkClient, ns Namespace, name string, fields map[string]string) error {
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	params.Add("name", name)
+	for field, fieldType := range fields {
+		params.Add("field."+field, fieldType)
+	}
+
+	_, err := doRequest(client, http.MethodPost, configPath(ns), nil, "application/x-www-form-urlencoded", params.Encode())
+	if err != nil {
+		return fmt.Errorf("collection creation : %w", err)
+	}
+	return nil
+}
+
+// Creates the collection unless it already exists
+func EnsureCollection(client *splunk.SplunkClient, ns Namespace, name string, fields map[string]string) error {
+
+	err := CreateCollection(client, ns, name, fields)
+	if splunk.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+// Lists the names of the collections of the namespace
+func ListCollections(client *splunk.SplunkClient, ns Namespace) ([]string, error) {
+
+	body, err := doRequest(client, http.MethodGet, configPath(ns), url.Values{"output_mode": {"json"}, "count": {"0"}}, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("collections listing : %w", err)
+	}
+
+	var list collectionList
+	err = json.Unmarshal(body, &list)
+	if err != nil {
+		return nil, fmt.Errorf("could not map list of collections to datastructure: %w", err)
+	}
+
+	names := make([]string, 0, len(list.Entry))
+	for _, entry := range list.Entry {
+		names = append(names, entry.Name)
+	}
+	return names, nil
+}
+
+// Deletes a collection and its records
+func DeleteCollection(client *splunk.SplunkClient, ns Namespace, name string) error {
+
+	_, err := doRequest(client, http.MethodDelete, configPath(ns)+"/"+url.PathEscape(name), url.Values{"output_mode": {"json"}}, "", "")
+	if err != nil {
+		return fmt.Errorf("collection removing : %w", err)
+	}
+	return nil
+}
+
+// Inserts a record and returns its key, the key is generated by splunk unless the record has a _key field
+func InsertRecord(client *splunk.SplunkClient, ns Namespace, collection string, record interface{}) (string, error) {
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("record insertion : error while encoding the record : %w", err)
+	}
+
+	body, err := doRequest(client, http.MethodPost, dataPath(ns, collection), nil, "application/json", string(content))
+	if err != nil {
+		return "", fmt.Errorf("record insertion : %w", err)
+	}
+
+	var key recordKey
+	err = json.Unmarshal(body, &key)
+	if err != nil {
+		return "", fmt.Errorf("could not map the key of the record to datastructure: %w", err)
+	}
+	return key.Key, nil
+}
+
+// Reads the record with the given key into record, a SplunkError for which client.IsNotFound is true is returned if it does not exist
+func GetRecord(client *splunk.SplunkClient, ns Namespace, collection string, key string, record interface{}) error {
+
+	body, err := doRequest(client, http.MethodGet, dataPath(ns, collection)+"/"+url.PathEscape(key), nil, "", "")
+	if err != nil {
+		return fmt.Errorf("record reading : %w", err)
+	}
+
+	err = json.Unmarshal(body, record)
+	if err != nil {
+		return fmt.Errorf("could not map the record to datastructure: %w", err)
+	}
+	return nil
+}
+
+// Replaces the record with the given key
+func UpdateRecord(client *splunk.SplunkClient, ns Namespace, collection string, key string, record interface{}) error {
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("record update : error while encoding the record : %w", err)
+	}
+
+	_, err = doRequest(client, http.MethodPost, dataPath(ns, collection)+"/"+url.PathEscape(key), nil, "application/json", string(content))
+	if err != nil {
+		return fmt.Errorf("record update : %w", err)
+	}
+	return nil
+}
+
+// Deletes the record with the given key
+func DeleteRecord(client *splunk.SplunkClient, ns Namespace, collection string, key string) error {
+
+	_, err := doRequest(client, http.MethodDelete, dataPath(ns, collection)+"/"+url.PathEscape(key), nil, "", "")
+	if err != nil {
+		return fmt.Errorf("record removing : %w", err)
+	}
+	return nil
+}
+
+// Reads the records matching the query into records, which must be a pointer to a slice
+func QueryRecords(client *splunk.SplunkClient, ns Namespace, collection string, query Query, records interface{}) error {
+
+	params, err := query.params()
+	if err != nil {
+		return err
+	}
+
+	body, err := doRequest(client, http.MethodGet, dataPath(ns, collection), params, "", "")
+	if err != nil {
+		return fmt.Errorf("records query : %w", err)
+	}
+
+	err = json.Unmarshal(body, records)
+	if err != nil {
+		return fmt.Errorf("could not map the records to datastructure: %w", err)
+	}
+	return nil
+}
+
+// Deletes the records matching the filter, all the records of the collection if the filter is empty
+func DeleteRecords(client *splunk.SplunkClient, ns Namespace, collection string, filter map[string]interface{}) error {
+
+	params, err := Query{Filter: filter}.params()
+	if err != nil {
+		return err
+	}
+
+	_, err = doRequest(client, http.MethodDelete, dataPath(ns, collection), params, "", "")
+	if err != nil {
+		return fmt.Errorf("records removing : %w", err)
+	}
+	return nil
+}
+
+// Inserts or replaces the records (a slice) in a single request and returns their keys
+func BatchSave(client *splunk.SplunkClient, ns Namespace, collection string, records interface{}) ([]string, error) {
+
+	content, err := json.Marshal(records)
+	if err != nil {
+		return nil, fmt.Errorf("records batch save : error while encoding the records : %w", err)
+	}
+
+	body, err := doRequest(client, http.MethodPost, dataPath(ns, collection)+"/batch_save", nil, "application/json", string(content))
+	if err != nil {
+		return nil, fmt.Errorf("records batch save : %w", err)
+	}
+
+	var keys []string
+	err = json.Unmarshal(body, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("could not map the keys of the records to datastructure: %w", err)
+	}
+	return keys, nil
+}
+
+// return the url parameters of the query
+func (query Query) params() (url.Values, error) {
+	params := url.Values{}
+
+	if len(query.Filter) > 0 {
+		filter, err := json.Marshal(query.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("error while encoding the filter : %w", err)
+		}
+		params.Add("query", string(filter))
+	}
+	for _, field := range query.Fields {
+		params.Add("fields", field)
+	}
+	if query.Sort != "" {
+		params.Add("sort", query.Sort)
+	}
+	if query.Limit > 0 {
+		params.Add("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Skip > 0 {
+		params.Add("skip", strconv.Itoa(query.Skip))
+	}
+	return params, nil
+}
+
+// sends the request with the parameters in the url and returns the body of the response
+func doRequest(client *splunk.SplunkClient, method string, path string, params url.Values, contentType string, body string) ([]byte, error) {
+
+	// create the endpoint for the request, a local value since the client is shared by concurrent requests
+	endpoint := client.BuildEndpoint(path)
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+
+	resp, err := splunk.MakeHttpRequestToEndpoint(client, method, endpoint, headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("error while making the %s request : %w", method, err)
+	}
+
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the body of the %s request : %w", method, err)
+	}
+	// handle error
+	err = splunk.CheckHttpResponse(resp, respBody, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http error : %w", err)
+	}
+
+	return respBody, nil
+}
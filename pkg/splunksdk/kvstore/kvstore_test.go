@@ -0,0 +1,198 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	utils "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+type testRecord struct {
+	Key     string  `json:"_key,omitempty"`
+	Project string  `json:"project"`
+	Score   float64 `json:"score"`
+}
+
+// Builds a fake kv store keeping the records of the collection "baselines" in memory
+//
+//	the queries only support equality filters
+func buildMockKVStore(t *testing.T) *httptest.Server {
+	const dataPrefix = "/servicesNS/nobody/search/storage/collections/data/baselines"
+	var mu sync.Mutex
+	records := map[string]map[string]interface{}{}
+	nextKey := 0
+
+	save := func(record map[string]interface{}) string {
+		key, _ := record["_key"].(string)
+		if key == "" {
+			nextKey++
+			key = "key" + string(rune('0'+nextKey))
+			record["_key"] = key
+		}
+		records[key] = record
+		return key
+	}
+	matches := func(record map[string]interface{}, query string) bool {
+		if query == "" {
+			return true
+		}
+		var filter map[string]interface{}
+		_ = json.Unmarshal([]byte(query), &filter)
+		for field, value := range filter {
+			if record[field] != value {
+				return false
+			}
+		}
+		return true
+	}
+
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/storage/collections/config"):
+			_, _ = w.Write([]byte(`{"entry":[{"name":"baselines"}]}`))
+		case r.URL.Path == dataPrefix+"/batch_save":
+			var batch []map[string]interface{}
+			_ = json.Unmarshal(body, &batch)
+			keys := []string{}
+			for _, record := range batch {
+				keys = append(keys, save(record))
+			}
+			_ = json.NewEncoder(w).Encode(keys)
+		case r.URL.Path == dataPrefix:
+			switch r.Method {
+			case http.MethodPost:
+				var record map[string]interface{}
+				_ = json.Unmarshal(body, &record)
+				_ = json.NewEncoder(w).Encode(map[string]string{"_key": save(record)})
+			case http.MethodGet:
+				result := []map[string]interface{}{}
+				for _, record := range records {
+					if matches(record, r.URL.Query().Get("query")) {
+						result = append(result, record)
+					}
+				}
+				_ = json.NewEncoder(w).Encode(result)
+			case http.MethodDelete:
+				for key, record := range records {
+					if matches(record, r.URL.Query().Get("query")) {
+						delete(records, key)
+					}
+				}
+			}
+		case strings.HasPrefix(r.URL.Path, dataPrefix+"/"):
+			key := strings.TrimPrefix(r.URL.Path, dataPrefix+"/")
+			record, ok := records[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"Could not find object."}]}`))
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				_ = json.NewEncoder(w).Encode(record)
+			case http.MethodPost:
+				var updated map[string]interface{}
+				_ = json.Unmarshal(body, &updated)
+				updated["_key"] = key
+				records[key] = updated
+				_ = json.NewEncoder(w).Encode(map[string]string{"_key": key})
+			case http.MethodDelete:
+				delete(records, key)
+			}
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestRecordsCRUD(t *testing.T) {
+	server := buildMockKVStore(t)
+	defer server.Close()
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, utils.GetTestHostname(server), utils.GetTestPort(server), utils.GetTestToken(), true)
+
+	collections, err := ListCollections(client, DefaultNamespace)
+	if err != nil || len(collections) != 1 || collections[0] != "baselines" {
+		t.Fatalf("Unexpected collections %v : %v", collections, err)
+	}
+
+	key, err := InsertRecord(client, DefaultNamespace, "baselines", testRecord{Project: "fulltour", Score: 90})
+	if err != nil || key == "" {
+		t.Fatalf("Could not insert the record : %v", err)
+	}
+
+	var record testRecord
+	err = GetRecord(client, DefaultNamespace, "baselines", key, &record)
+	if err != nil || record.Score != 90 || record.Key != key {
+		t.Fatalf("Unexpected record %+v : %v", record, err)
+	}
+
+	err = UpdateRecord(client, DefaultNamespace, "baselines", key, testRecord{Project: "fulltour", Score: 95})
+	if err != nil {
+		t.Fatalf("Could not update the record : %v", err)
+	}
+	err = GetRecord(client, DefaultNamespace, "baselines", key, &record)
+	if err != nil || record.Score != 95 {
+		t.Fatalf("Expected the record to be updated but got %+v : %v", record, err)
+	}
+
+	err = DeleteRecord(client, DefaultNamespace, "baselines", key)
+	if err != nil {
+		t.Fatalf("Could not delete the record : %v", err)
+	}
+	err = GetRecord(client, DefaultNamespace, "baselines", key, &record)
+	if !splunk.IsNotFound(err) {
+		t.Fatalf("Expected a not found error but got %v", err)
+	}
+}
+
+func TestBatchSaveAndQuery(t *testing.T) {
+	server := buildMockKVStore(t)
+	defer server.Close()
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, utils.GetTestHostname(server), utils.GetTestPort(server), utils.GetTestToken(), true)
+
+	keys, err := BatchSave(client, DefaultNamespace, "baselines", []testRecord{
+		{Key: "a", Project: "fulltour", Score: 90},
+		{Key: "b", Project: "fulltour", Score: 80},
+		{Key: "c", Project: "podtatohead", Score: 70},
+	})
+	if err != nil || len(keys) != 3 {
+		t.Fatalf("Unexpected keys %v : %v", keys, err)
+	}
+
+	var records []testRecord
+	err = QueryRecords(client, DefaultNamespace, "baselines", Query{Filter: map[string]interface{}{"project": "fulltour"}, Limit: 10}, &records)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected the 2 records of the project but got %v : %v", records, err)
+	}
+
+	err = DeleteRecords(client, DefaultNamespace, "baselines", map[string]interface{}{"project": "fulltour"})
+	if err != nil {
+		t.Fatalf("Could not delete the records : %v", err)
+	}
+	records = nil
+	err = QueryRecords(client, DefaultNamespace, "baselines", Query{}, &records)
+	if err != nil || len(records) != 1 || records[0].Key != "c" {
+		t.Fatalf("Expected only the record of the other project but got %v : %v", records, err)
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	params, err := Query{Filter: map[string]interface{}{"score": map[string]int{"$gt": 90}}, Fields: []string{"project", "score"}, Sort: "score:-1", Limit: 5, Skip: 10}.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Get("query") != `{"score":{"$gt":90}}` || len(params["fields"]) != 2 || params.Get("sort") != "score:-1" || params.Get("limit") != "5" || params.Get("skip") != "10" {
+		t.Fatalf("Unexpected parameters %v", params)
+	}
+}
@@ -14,6 +14,7 @@ const GetAlertsNames = "getAlertsNames"
 const GetTriggeredAlerts = "getTriggeredAlerts"
 const CreateAlerts = "createAlerts"
 const GetTriggeredInstances = "getTriggeredInstances"
+const GetJobStatus = "getJobStatus"
 
 // mock an http server
 func MockRequest(response string, sslVerificationActivated bool) *httptest.Server {
@@ -61,6 +62,8 @@ func writeResponses(responses interface{}, w http.ResponseWriter, r *http.Reques
 					_, _ = fmt.Fprintln(w, response[GetTriggeredInstances])
 				case response[GetAlertsNames] != nil && strings.Contains(r.URL.Path, "services/saved/searches/"):
 					_, _ = fmt.Fprintln(w, response[GetAlertsNames])
+				case response[GetJobStatus] != nil && strings.Contains(r.URL.Path, JobsPathv2) && !strings.HasSuffix(r.URL.Path, "/results"):
+					_, _ = fmt.Fprintln(w, response[GetJobStatus])
 				case response[method] != nil:
 					_, _ = fmt.Fprintln(w, response[method])
 				}
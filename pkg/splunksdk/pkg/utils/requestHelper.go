@@ -1,13 +1,27 @@
 package utils
 
 import (
-	"net"
 	"strings"
-
-	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"unicode"
 )
 
+// generating commands of the metrics and accelerated data, which can not follow a search
+var metricCommands = map[string]bool{
+	"mstats":   true,
+	"mcatalog": true,
+	"mpreview": true,
+	"tstats":   true,
+}
+
 func ValidateSearchQuery(searchQuery string) string {
+	searchQuery = strings.TrimSpace(searchQuery)
+	// the queries starting with a generating command (| tstats, | mstats, | inputlookup...) are sent as is
+	if strings.HasPrefix(searchQuery, "|") {
+		return searchQuery
+	}
+	if query, ok := withMetricPipe(searchQuery); ok {
+		return query
+	}
 	// the search must start with the "search" keyword
 	const query_prefix = "search "
 	if !strings.HasPrefix(searchQuery, query_prefix) {
@@ -17,6 +31,11 @@ func ValidateSearchQuery(searchQuery string) string {
 }
 
 func ValidateAlertQuery(alertQuery string) string {
+	alertQuery = strings.TrimSpace(alertQuery)
+	// a saved search starting with a metric command without the pipe would be run as a term search
+	if query, ok := withMetricPipe(alertQuery); ok {
+		return query
+	}
 	// the search must start with the "search" keyword
 	const query_prefix = "search "
 	if strings.HasPrefix(alertQuery, query_prefix) {
@@ -25,17 +44,11 @@ func ValidateAlertQuery(alertQuery string) string {
 	return alertQuery
 }
 
-func CreateEndpoint(client *splunk.SplunkClient, service string) {
-	host := client.Host
-	port := client.Port
-
-	switch {
-	case strings.HasPrefix(host, "https://"):
-		host = strings.Replace(host, "https://", "", 1)
-	case strings.HasPrefix(host, "http://"):
-		host = strings.Replace(host, "http://", "", 1)
+// add the pipe before the metric commands written without it, a plain search can not run them
+func withMetricPipe(query string) (string, bool) {
+	command := strings.FieldsFunc(query, unicode.IsSpace)
+	if len(command) > 0 && metricCommands[strings.ToLower(command[0])] {
+		return "| " + query, true
 	}
-
-	client.Endpoint = "https://" + net.JoinHostPort(host, port) + "/" + service
-	client.Endpoint = strings.ReplaceAll(client.Endpoint, " ", "")
+	return query, false
 }
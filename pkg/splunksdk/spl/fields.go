@@ -0,0 +1,182 @@
+package spl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// commands computing aggregated results
+var aggregationCommands = map[string]bool{
+	"stats":     true,
+	"tstats":    true,
+	"mstats":    true,
+	"chart":     true,
+	"timechart": true,
+}
+
+// keywords ending the aggregations of an aggregation command
+var aggregationClauses = map[string]bool{
+	"by":    true,
+	"over":  true,
+	"where": true,
+	"from":  true,
+}
+
+// return the commands of the query with their arguments as written
+//
+//	the first command is a search unless the query starts with a generating command
+func Commands(query string) []Command {
+	var commands []Command
+
+	for i, part := range SplitCommands(query) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, args := part, ""
+		if index := strings.IndexFunc(part, unicode.IsSpace); index >= 0 {
+			name, args = part[:index], part[index:]
+		}
+		name = strings.ToLower(name)
+		if i == 0 && name != "search" {
+			commands = append(commands, Command{Command: "search", RawArgs: part})
+			continue
+		}
+		commands = append(commands, Command{Command: name, RawArgs: strings.TrimSpace(args)})
+	}
+	return commands
+}
+
+// ResultField returns the field of the results holding the value computed by the query, see ParsedQuery.ResultField
+func ResultField(query string) (string, error) {
+	return resultField(Commands(query))
+}
+
+// ResultField returns the field of the results holding the value computed by the query
+//
+//	it is the output of the last aggregation (stats, tstats, mstats, chart or timechart), following its AS alias,
+//	or the last field set by an eval after it, and it follows the rename, fields and table commands.
+//	An error is returned if the query has no aggregation or if it outputs several fields
+func (p *ParsedQuery) ResultField() (string, error) {
+	return resultField(p.Commands)
+}
+
+func resultField(commands []Command) (string, error) {
+	var fields []string
+	computed := ""
+	aggregated := false
+
+	for _, command := range commands {
+		switch name := strings.ToLower(command.Command); {
+		case aggregationCommands[name]:
+			outputs, err := aggregationFields(name, command.RawArgs)
+			if err != nil {
+				return "", err
+			}
+			fields = outputs
+			computed = ""
+			aggregated = true
+
+		case name == "eval":
+			for _, assignment := range splitTopLevel(command.RawArgs, func(r rune) bool { return r == ',' }, true) {
+				index := indexTopLevel(assignment, '=')
+				if index <= 0 {
+					continue
+				}
+				computed = unquote(strings.TrimSpace(assignment[:index]))
+			}
+
+		case name == "rename":
+			args := splitArguments(command.RawArgs)
+			for i := 0; i+2 < len(args); i += 3 {
+				if !strings.EqualFold(args[i+1], "as") {
+					return "", fmt.Errorf("could not read the rename command %s", command.RawArgs)
+				}
+				from, to := unquote(args[i]), unquote(args[i+2])
+				for j := range fields {
+					if fields[j] == from {
+						fields[j] = to
+					}
+				}
+				if computed == from {
+					computed = to
+				}
+			}
+
+		case name == "fields" || name == "table":
+			args := splitArguments(command.RawArgs)
+			remove := len(args) > 0 && args[0] == "-"
+			if len(args) > 0 && (args[0] == "-" || args[0] == "+") {
+				args = args[1:]
+			}
+			listed := map[string]bool{}
+			for _, arg := range args {
+				listed[unquote(arg)] = true
+			}
+
+			kept := []string{}
+			for _, field := range fields {
+				if listed[field] != remove {
+					kept = append(kept, field)
+				}
+			}
+			fields = kept
+			if computed != "" && listed[computed] == remove {
+				computed = ""
+			}
+		}
+	}
+
+	switch {
+	case computed != "":
+		return computed, nil
+	case !aggregated:
+		return "", fmt.Errorf("no aggregation (stats, tstats, mstats, chart or timechart) found in the query")
+	case len(fields) == 1:
+		return fields[0], nil
+	case len(fields) == 0:
+		return "", fmt.Errorf("the aggregation of the query has no output field")
+	default:
+		return "", fmt.Errorf("the query returns several fields (%s), the result field must be given", strings.Join(fields, ", "))
+	}
+}
+
+// return the names of the fields computed by the aggregation command, the split by fields excluded
+func aggregationFields(command string, args string) ([]string, error) {
+	var fields []string
+
+	tokens := splitArguments(args)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		lower := strings.ToLower(token)
+
+		if aggregationClauses[lower] {
+			// the columns of a chart split by a field are named after its values
+			if lower == "by" && (command == "chart" || command == "timechart") {
+				return nil, fmt.Errorf("the fields of a %s split by a field cannot be determined", command)
+			}
+			break
+		}
+
+		// options like span=1m or prestats=t
+		equal := strings.Index(token, "=")
+		parenthesis := strings.Index(token, "(")
+		if equal >= 0 && (parenthesis < 0 || equal < parenthesis) {
+			continue
+		}
+
+		if lower == "as" && i+1 < len(tokens) && len(fields) > 0 {
+			fields[len(fields)-1] = unquote(tokens[i+1])
+			i++
+			continue
+		}
+		fields = append(fields, token)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no aggregation found in the %s command", command)
+	}
+	return fields, nil
+}
@@ -0,0 +1,62 @@
+package spl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResultField(t *testing.T) {
+
+	tests := []struct {
+		query string
+		want  string
+		err   string
+	}{
+		{query: `source="http:podtato-error" (index="keptn-splunk-dev") "[error]" | stats count`, want: "count"},
+		{query: `index=main | stats count AS errors`, want: "errors"},
+		{query: `index=main | stats avg(duration) as "avg duration" by host`, want: "avg duration"},
+		{query: `index=main | stats count(eval(status>=500)) AS errors, count AS total | eval rate=errors/total`, want: "rate"},
+		{query: `index=main | stats count AS errors, count AS total | eval rate=errors/total, pct=rate*100`, want: "pct"},
+		{query: `| tstats count where index=main by host`, want: "count"},
+		{query: `| mstats prestats=f avg(_value) AS cpu WHERE index=metrics metric_name=cpu.usage span=1m`, want: "cpu"},
+		{query: `index=main | timechart span=1m count`, want: "count"},
+		{query: `index=main | stats count | rename count AS hits`, want: "hits"},
+		{query: `index=main stats_field=1 | stats max(latency) AS latency_stats`, want: "latency_stats"},
+		{query: `index=main | stats count AS errors, dc(user) AS users | fields errors`, want: "errors"},
+		{query: `index=main | stats count AS errors, dc(user) AS users | table - users`, want: "errors"},
+		{query: `index=main message="a | stats count" | stats count AS errors`, want: "errors"},
+		{query: `index=main [search index=users | stats count by user | fields user] | stats dc(user)`, want: "dc(user)"},
+		{query: `index=main | head 10`, err: "no aggregation"},
+		{query: `index=main | stats count AS errors, dc(user) AS users`, err: "several fields (errors, users)"},
+		{query: `index=main | timechart count by host`, err: "split by a field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ResultField(tt.query)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected an error containing %q but got %q, %v", tt.err, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Expected %q but got %q, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestParsedQueryResultField(t *testing.T) {
+	// the macros are expanded by the parser
+	parsed := ParsedQuery{Commands: []Command{
+		{Command: "search", RawArgs: "index=main sourcetype=access"},
+		{Command: "stats", RawArgs: "count AS errors"},
+		{Command: "eval", RawArgs: "'error count'=errors"},
+	}}
+
+	got, err := parsed.ResultField()
+	if err != nil || got != "error count" {
+		t.Fatalf("Expected error count but got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,86 @@
+package spl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// field of the results of a metric query holding its value
+const MetricValueField = "value"
+
+// aggregations of mstats, the percentiles being written like p95 or perc95
+var metricAggregation = regexp.MustCompile(`^(avg|min|max|sum|count|dc|median|mode|range|stdev|stdevp|var|varp|latest|earliest|rate|p[0-9]{1,2}|(exact|upper)?perc[0-9]{1,2})$`)
+
+// spans of mstats, e.g. 30s, 5m or 1h
+var metricSpan = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d|w)?$`)
+
+// MetricQuery aggregates the values of a metric of the metrics indexes into a single value
+//
+//	with a span, the metric is aggregated by span and the value is the aggregation of the values of the spans,
+//	e.g. the worst 5 minutes average latency
+type MetricQuery struct {
+	// name of the metric, e.g. cpu.usage
+	Name string
+	// metrics index of the metric, all the metrics indexes if empty
+	Index string
+	// aggregation of the values of the metric (avg, max, p95...), avg if empty
+	Aggregation string
+	// values of the dimensions the metric is filtered on, wildcards are allowed
+	Dimensions map[string]string
+	// time span the metric is aggregated by, e.g. 5m
+	Span string
+	// aggregation of the values of the spans, max if empty
+	SpanAggregation string
+}
+
+// Query returns the mstats query computing the value of the metric in the field value
+//
+//	| mstats avg(_value) AS value WHERE index="metrics" metric_name="cpu.usage" host="web-1"
+func (m MetricQuery) Query() (*Query, error) {
+	if m.Name == "" {
+		return nil, fmt.Errorf("no metric name")
+	}
+	aggregation := m.Aggregation
+	if aggregation == "" {
+		aggregation = "avg"
+	}
+	if !metricAggregation.MatchString(aggregation) {
+		return nil, fmt.Errorf("unknown aggregation %s of the metric %s", aggregation, m.Name)
+	}
+	index := m.Index
+	if index == "" {
+		index = "*"
+	}
+
+	terms := []string{Term("index", index), Term("metric_name", m.Name)}
+	dimensions := make([]string, 0, len(m.Dimensions))
+	for dimension := range m.Dimensions {
+		dimensions = append(dimensions, dimension)
+	}
+	sort.Strings(dimensions)
+	for _, dimension := range dimensions {
+		terms = append(terms, Term(dimension, m.Dimensions[dimension]))
+	}
+
+	mstats := fmt.Sprintf("mstats %s(_value) AS %s WHERE %s", aggregation, MetricValueField, strings.Join(terms, " "))
+	if m.Span == "" {
+		return NewQuery("").Pipe(mstats), nil
+	}
+
+	if !metricSpan.MatchString(m.Span) {
+		return nil, fmt.Errorf("invalid span %s of the metric %s", m.Span, m.Name)
+	}
+	spanAggregation := m.SpanAggregation
+	if spanAggregation == "" {
+		spanAggregation = "max"
+	}
+	if !metricAggregation.MatchString(spanAggregation) {
+		return nil, fmt.Errorf("unknown span aggregation %s of the metric %s", spanAggregation, m.Name)
+	}
+
+	return NewQuery("").
+		Pipe(mstats + " span=" + m.Span).
+		Pipe(fmt.Sprintf("stats %s(%s) AS %s", spanAggregation, MetricValueField, MetricValueField)), nil
+}
@@ -0,0 +1,101 @@
+package spl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	utils "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+const parserPath = "services/search/parser"
+
+// ParsedQuery is the query as understood by the search parser of splunk
+type ParsedQuery struct {
+	Commands []Command `json:"commands"`
+	// part of the query run on the indexers
+	RemoteSearch string `json:"remoteSearch"`
+	// part of the query transforming the results, e.g. the stats commands
+	ReportsSearch string `json:"reportsSearch"`
+}
+
+// Command is a command of a parsed query
+type Command struct {
+	// name of the command, e.g. search, stats, eval
+	Command string `json:"command"`
+	// arguments of the command as written in the query
+	RawArgs string `json:"rawargs"`
+	// streaming or report
+	Pipeline     string `json:"pipeline"`
+	IsGenerating bool   `json:"isGenerating"`
+}
+
+// InvalidQueryError is returned when splunk rejects the syntax of a query
+type InvalidQueryError struct {
+	Query    string
+	Messages []string
+}
+
+func (e *InvalidQueryError) Error() string {
+	return fmt.Sprintf("invalid query %s : %s", e.Query, strings.Join(e.Messages, "; "))
+}
+
+// true if the error is an InvalidQueryError, false if the query could not be validated for another reason
+func IsInvalidQuery(err error) bool {
+	var invalid *InvalidQueryError
+	return errors.As(err, &invalid)
+}
+
+// Parses the query with the search parser of splunk, an InvalidQueryError is returned if its syntax is wrong
+//
+//	"search" is added in front of the query unless it starts with a generating command
+func Parse(client *splunk.SplunkClient, query string) (*ParsedQuery, error) {
+
+	query = utils.ValidateSearchQuery(query)
+
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("output_mode", "json")
+	params.Add("parse_only", "t")
+
+	endpoint := client.BuildEndpoint(parserPath) + "?" + params.Encode()
+
+	resp, err := splunk.MakeHttpRequestToEndpoint(client, http.MethodGet, endpoint, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("query parsing : error while making the get request : %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("query parsing : error while getting the body of the get request : %w", err)
+	}
+	// splunk answers with a bad request if the syntax is wrong
+	if resp.StatusCode == http.StatusBadRequest {
+		spErr := splunk.NewSplunkError(resp, body, endpoint)
+		return nil, &InvalidQueryError{Query: query, Messages: spErr.Messages}
+	}
+	err = splunk.CheckHttpResponse(resp, body, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("query parsing : http error : %w", err)
+	}
+
+	parsed := &ParsedQuery{}
+	err = json.Unmarshal(body, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("could not map the parsed query to datastructure: %w", err)
+	}
+	return parsed, nil
+}
+
+// Checks the syntax of the query with the search parser of splunk, see Parse
+func Validate(client *splunk.SplunkClient, query string) error {
+	_, err := Parse(client, query)
+	return err
+}
@@ -0,0 +1,58 @@
+package spl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	splunkTest "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/pkg/utils"
+)
+
+// Builds a fake search parser rejecting the queries using the command "stat"
+func buildMockParser(t *testing.T) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+parserPath || r.URL.Query().Get("parse_only") != "t" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+
+		switch q := r.URL.Query().Get("q"); q {
+		case "search index=main | stats count AS errors":
+			_, _ = fmt.Fprint(w, `{"remoteSearch":"litsearch index=main","reportsSearch":"stats count AS errors","commands":[
+				{"command":"search","rawargs":"index=main","pipeline":"streaming","isGenerating":true},
+				{"command":"stats","rawargs":"count AS errors","pipeline":"report","isGenerating":false}]}`)
+		case "| tstats count where index=main":
+			_, _ = fmt.Fprint(w, `{"commands":[{"command":"tstats","rawargs":"count where index=main","pipeline":"report","isGenerating":true}]}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `{"messages":[{"type":"ERROR","text":"Unknown search command 'stat'."}]}`)
+		}
+	}))
+}
+
+func TestParse(t *testing.T) {
+	server := buildMockParser(t)
+	defer server.Close()
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{Timeout: 60 * time.Second}, splunkTest.GetTestHostname(server), splunkTest.GetTestPort(server), splunkTest.GetTestToken(), true)
+
+	parsed, err := Parse(client, "index=main | stats count AS errors")
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if len(parsed.Commands) != 2 || parsed.Commands[1].Command != "stats" || parsed.Commands[1].RawArgs != "count AS errors" || parsed.ReportsSearch != "stats count AS errors" {
+		t.Fatalf("Unexpected parsed query %+v", parsed)
+	}
+
+	// generating commands are not prefixed by search
+	err = Validate(client, "| tstats count where index=main")
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	err = Validate(client, "index=main | stat count")
+	if !IsInvalidQuery(err) {
+		t.Fatalf("Expected an invalid query error but got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package spl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// names of fields usable without quotes
+var plainField = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Query composes a search from a base query and the commands piped after it
+//
+//	spl.NewQuery(`index="main" sourcetype="access"`).Where(spl.Compare("status", ">=", 500)).Pipe("stats count")
+type Query struct {
+	base     string
+	commands []string
+}
+
+// return a query starting with the given search or generating command
+func NewQuery(base string) *Query {
+	return &Query{base: strings.TrimSpace(base)}
+}
+
+// return a query searching the given terms, see Term
+func Search(terms ...string) *Query {
+	return NewQuery(strings.Join(terms, " "))
+}
+
+// true if the query starts with a generating command like | tstats, | mstats or | inputlookup, which must not be prefixed by "search"
+func IsGenerating(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "|")
+}
+
+// return the value as a double quoted string
+func Quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// return the name of the field as it must be written in eval and where expressions, in single quotes unless it is a plain name
+func QuoteField(name string) string {
+	if plainField.MatchString(name) {
+		return name
+	}
+	name = strings.ReplaceAll(name, `\`, `\\`)
+	name = strings.ReplaceAll(name, `'`, `\'`)
+	return "'" + name + "'"
+}
+
+// return a field=value term of a search, the value being quoted
+func Term(field string, value string) string {
+	if !plainField.MatchString(field) {
+		field = Quote(field)
+	}
+	return field + "=" + Quote(value)
+}
+
+// return a comparison of a field usable in eval and where expressions, the strings are quoted
+func Compare(field string, operator string, value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return QuoteField(field) + operator + Quote(v)
+	default:
+		return QuoteField(field) + operator + fmt.Sprint(v)
+	}
+}
+
+// filter the results with the given search terms
+func (q *Query) Search(terms ...string) *Query {
+	return q.Pipe("search " + strings.Join(terms, " "))
+}
+
+// filter the results with an eval expression
+func (q *Query) Where(expression string) *Query {
+	return q.Pipe("where " + expression)
+}
+
+// set the field to the result of an eval expression
+func (q *Query) Eval(field string, expression string) *Query {
+	return q.Pipe("eval " + QuoteField(field) + "=" + expression)
+}
+
+// append a command, e.g. "stats count by host"
+func (q *Query) Pipe(command string) *Query {
+	command = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "|"))
+	q.commands = append(q.commands, command)
+	return q
+}
+
+func (q *Query) String() string {
+	query := q.base
+	for _, command := range q.commands {
+		if query == "" {
+			// the query starts with a generating command
+			query = "| " + command
+			continue
+		}
+		query += " | " + command
+	}
+	return query
+}
@@ -0,0 +1,107 @@
+package spl
+
+import "testing"
+
+func TestQueryBuilder(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  string
+	}{
+		{
+			name:  "search with piped commands",
+			query: Search(Term("index", "main"), Term("source", `http:podtato "error"`)).Where(Compare("status", ">=", 500)).Pipe("stats count"),
+			want:  `index="main" source="http:podtato \"error\"" | where status>=500 | stats count`,
+		},
+		{
+			name:  "eval with a field needing quotes",
+			query: NewQuery("index=main").Eval("error rate", "errors/total").Where(Compare("error rate", ">", 0.1)),
+			want:  `index=main | eval 'error rate'=errors/total | where 'error rate'>0.1`,
+		},
+		{
+			name:  "generating command",
+			query: NewQuery("| tstats count where index=main by host").Search(Term("host", "web-1")),
+			want:  `| tstats count where index=main by host | search host="web-1"`,
+		},
+		{
+			name:  "query starting with a piped command",
+			query: NewQuery("").Pipe("| inputlookup hosts.csv").Where(Compare("env", "=", "prod")),
+			want:  `| inputlookup hosts.csv | where env="prod"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.String(); got != tt.want {
+				t.Fatalf("Expected %s but got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	if got := Quote(`C:\logs "quoted"`); got != `"C:\\logs \"quoted\""` {
+		t.Fatalf("Unexpected quoted value %s", got)
+	}
+	if got := QuoteField("it's"); got != `'it\'s'` {
+		t.Fatalf("Unexpected quoted field %s", got)
+	}
+	if got := Term("host name", "a"); got != `"host name"="a"` {
+		t.Fatalf("Unexpected term %s", got)
+	}
+	if !IsGenerating("  | mstats avg(cpu) WHERE index=metrics") || IsGenerating("index=main | stats count") {
+		t.Fatal("Expected only the query starting with a pipe to be generating")
+	}
+}
+
+// Tests that the same query written differently is normalized the same way
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"index=main  status=500 |stats count":            "index=main status=500 | stats count",
+		"  | tstats count\twhere index=main ":            "| tstats count where index=main",
+		`index=main msg="a  |  b" | stats count`:         `index=main msg="a  |  b" | stats count`,
+		"index=main [search  index=users | fields user]": "index=main [search  index=users | fields user]",
+	}
+	for query, expected := range tests {
+		if normalized := Normalize(query); normalized != expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", query, normalized, expected)
+		}
+	}
+}
+
+func TestMetricQuery(t *testing.T) {
+	tests := []struct {
+		metric MetricQuery
+		want   string
+	}{
+		{
+			metric: MetricQuery{Name: "cpu.usage"},
+			want:   `| mstats avg(_value) AS value WHERE index="*" metric_name="cpu.usage"`,
+		},
+		{
+			metric: MetricQuery{Name: "http.latency", Index: "metrics", Aggregation: "p95", Dimensions: map[string]string{"service": "carts", "env": "prod*"}},
+			want:   `| mstats p95(_value) AS value WHERE index="metrics" metric_name="http.latency" env="prod*" service="carts"`,
+		},
+		{
+			metric: MetricQuery{Name: "http.latency", Index: "metrics", Span: "5m"},
+			want:   `| mstats avg(_value) AS value WHERE index="metrics" metric_name="http.latency" span=5m | stats max(value) AS value`,
+		},
+	}
+
+	for _, tt := range tests {
+		query, err := tt.metric.Query()
+		if err != nil || query.String() != tt.want {
+			t.Errorf("Expected %s but got %v : %v", tt.want, query, err)
+		}
+		if field, err := ResultField(query.String()); err != nil || field != MetricValueField {
+			t.Errorf("Expected the result field %s of %s but got %s : %v", MetricValueField, query, field, err)
+		}
+	}
+
+	for _, invalid := range []MetricQuery{{}, {Name: "cpu.usage", Aggregation: "average"}, {Name: "cpu.usage", Span: "5 minutes"}} {
+		if _, err := invalid.Query(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+}
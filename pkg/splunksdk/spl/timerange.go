@@ -0,0 +1,141 @@
+package spl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// layout of the starttime and endtime modifiers
+const legacyTimeLayout = "01/02/2006:15:04:05"
+
+// TimeRange is the time bounds given by the time modifiers of a query, empty when not given
+type TimeRange struct {
+	// earliest time of the events, from earliest, starttime or starttimeu
+	Earliest string
+	// latest time of the events, from latest, endtime or endtimeu
+	Latest string
+}
+
+// generating commands taking time modifiers in their arguments
+var timeBoundedCommands = map[string]bool{
+	"search": true,
+	"tstats": true,
+	"mstats": true,
+}
+
+// ExtractTimeRange returns the time modifiers of the first command of the query and the query without them
+//
+//	the modifiers are read from the search, or the tstats or mstats generating command, outside quotes,
+//	parentheses and subsearches, so that a field like earliest_login or a quoted "earliest=-1h" is kept.
+//	The first value of a modifier wins, and earliest and latest take precedence over starttime and endtime.
+//	The values are relative times with an optional snap-to (-1d@d, @w1, now), epoch times or absolute times,
+//	the starttime and endtime ones (MM/DD/YYYY:HH:MM:SS, in UTC) are converted to epoch times.
+//	The index time modifiers (_index_earliest, _index_latest) are left in the query where splunk applies them
+func ExtractTimeRange(query string) (TimeRange, string) {
+	parts := scanTopLevel(query, func(r rune) bool { return r == '|' }, false)
+
+	// the first part is empty when the query starts with a generating command
+	command := parts[0]
+	if strings.TrimSpace(query[command.start:command.end]) == "" && len(parts) > 1 {
+		command = parts[1]
+		name := strings.Fields(query[command.start:command.end])
+		if len(name) == 0 || !timeBoundedCommands[strings.ToLower(name[0])] {
+			return TimeRange{}, query
+		}
+	}
+
+	text := query[command.start:command.end]
+	modifiers := map[string]string{}
+	var removed []span
+	for _, token := range scanTopLevel(text, unicode.IsSpace, true) {
+		name, value, ok := timeModifier(text[token.start:token.end])
+		if !ok {
+			continue
+		}
+		if _, found := modifiers[name]; !found {
+			modifiers[name] = value
+		}
+		removed = append(removed, token)
+	}
+	if len(removed) == 0 {
+		return TimeRange{}, query
+	}
+
+	timeRange := TimeRange{
+		Earliest: modifiers["earliest"],
+		Latest:   modifiers["latest"],
+	}
+	if timeRange.Earliest == "" {
+		timeRange.Earliest = firstNonEmpty(modifiers["starttimeu"], modifiers["starttime"])
+	}
+	if timeRange.Latest == "" {
+		timeRange.Latest = firstNonEmpty(modifiers["endtimeu"], modifiers["endtime"])
+	}
+
+	return timeRange, query[:command.start] + removeSpans(text, removed) + query[command.end:]
+}
+
+// return the name and the value of a time modifier token like earliest=-1d@d, ok is false for other tokens
+func timeModifier(token string) (string, string, bool) {
+	index := strings.IndexByte(token, '=')
+	if index < 0 {
+		return "", "", false
+	}
+	name, value := strings.ToLower(token[:index]), unquote(token[index+1:])
+	if value == "" {
+		return "", "", false
+	}
+
+	switch name {
+	case "earliest", "latest":
+		return name, value, true
+	case "starttimeu", "endtimeu":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", "", false
+		}
+		return name, value, true
+	case "starttime", "endtime":
+		// a time in another format is left to splunk
+		parsed, err := time.ParseInLocation(legacyTimeLayout, value, time.UTC)
+		if err != nil {
+			return "", "", false
+		}
+		return name, strconv.FormatInt(parsed.Unix(), 10), true
+	}
+	return "", "", false
+}
+
+// remove the spans of text with the spaces before them, the text is kept as is elsewhere
+func removeSpans(text string, spans []span) string {
+	var result strings.Builder
+	last := 0
+	for _, removed := range spans {
+		start := removed.start
+		for start > last && unicode.IsSpace(rune(text[start-1])) {
+			start--
+		}
+		// remove the spaces after the span instead if it begins the text
+		if start == 0 {
+			last = removed.end
+			for last < len(text) && unicode.IsSpace(rune(text[last])) {
+				last++
+			}
+			continue
+		}
+		result.WriteString(text[last:start])
+		last = removed.end
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package spl
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// Tests the extraction of the time modifiers of some queries
+func TestExtractTimeRange(t *testing.T) {
+	tests := []struct {
+		query         string
+		expectedRange TimeRange
+		expectedQuery string
+	}{
+		{"index=main earliest=-2m latest=+2m | stats count", TimeRange{"-2m", "+2m"}, "index=main | stats count"},
+		{"earliest=-1d@d index=main | stats count", TimeRange{Earliest: "-1d@d"}, "index=main | stats count"},
+		{`search index=main latest="@d" | stats count`, TimeRange{Latest: "@d"}, "search index=main | stats count"},
+		{"index=main earliest=1697712000 latest=1697715600.5", TimeRange{"1697712000", "1697715600.5"}, "index=main"},
+		{"index=main earliest=-2m earliest=-1m | stats count", TimeRange{Earliest: "-2m"}, "index=main | stats count"},
+		// field names and values containing the modifiers are kept
+		{"index=main earliest_login=-1h | stats count", TimeRange{}, "index=main earliest_login=-1h | stats count"},
+		{`index=main "earliest=-1h" message='latest=now' | stats count`, TimeRange{}, `index=main "earliest=-1h" message='latest=now' | stats count`},
+		{"index=main _index_earliest=-1h | stats count", TimeRange{}, "index=main _index_earliest=-1h | stats count"},
+		// only the base search is bounded
+		{"index=main [search index=users earliest=-7d | fields user] | stats count", TimeRange{}, "index=main [search index=users earliest=-7d | fields user] | stats count"},
+		{"index=main | search earliest=-1h | stats count", TimeRange{}, "index=main | search earliest=-1h | stats count"},
+		{"index=main (earliest=-1h OR latest=now) | stats count", TimeRange{}, "index=main (earliest=-1h OR latest=now) | stats count"},
+		// generating commands
+		{"| tstats count where index=main earliest=-1h by host", TimeRange{Earliest: "-1h"}, "| tstats count where index=main by host"},
+		{"| inputlookup users earliest=-1h", TimeRange{}, "| inputlookup users earliest=-1h"},
+		// legacy modifiers
+		{"index=main starttime=10/19/2026:00:00:00 endtime=10/19/2026:01:00:00", TimeRange{"1792368000", "1792371600"}, "index=main"},
+		{"index=main starttimeu=1697712000 endtimeu=1697715600", TimeRange{"1697712000", "1697715600"}, "index=main"},
+		{"index=main starttimeu=1697712000 earliest=-1h", TimeRange{Earliest: "-1h"}, "index=main"},
+		{"index=main starttime=yesterday", TimeRange{}, "index=main starttime=yesterday"},
+	}
+
+	for _, test := range tests {
+		timeRange, query := ExtractTimeRange(test.query)
+		if timeRange != test.expectedRange || query != test.expectedQuery {
+			t.Errorf("ExtractTimeRange(%s) = %+v, %s, expected %+v, %s", test.query, timeRange, query, test.expectedRange, test.expectedQuery)
+		}
+	}
+}
+
+// pieces of queries which are not time modifiers
+var plainTokens = []string{
+	"index=main", "sourcetype=access_combined", "status>=500", "NOT", "host=web*", "earliest_login=-1h",
+	"last_latest=now", `"earliest=-1h"`, `message="latest = now"`, "_index_earliest=-1d", "(earliest=-2h OR host=db)",
+	"[search index=users earliest=-7d | fields user]", "starttime=yesterday",
+}
+
+// values of the time modifiers
+var timeValues = []string{"-1h", "-1d@d", "@w1", "now", "0", "1697712000", "-30m@h", "+2m", `"-15m"`}
+
+// a base search with its expected time range
+type generatedQuery struct {
+	Query     string
+	Range     TimeRange
+	Remaining []string
+}
+
+// Generate builds a random base search mixing plain tokens and time modifiers
+func (generatedQuery) Generate(random *rand.Rand, size int) reflect.Value {
+	var tokens, remaining []string
+	var timeRange TimeRange
+
+	for i := 0; i < random.Intn(size+1)+1; i++ {
+		switch random.Intn(4) {
+		case 0:
+			value := timeValues[random.Intn(len(timeValues))]
+			tokens = append(tokens, "earliest="+value)
+			if timeRange.Earliest == "" {
+				timeRange.Earliest = strings.Trim(value, `"`)
+			}
+		case 1:
+			value := timeValues[random.Intn(len(timeValues))]
+			tokens = append(tokens, "latest="+value)
+			if timeRange.Latest == "" {
+				timeRange.Latest = strings.Trim(value, `"`)
+			}
+		default:
+			token := plainTokens[random.Intn(len(plainTokens))]
+			tokens = append(tokens, token)
+			remaining = append(remaining, token)
+		}
+	}
+
+	query := strings.Join(tokens, strings.Repeat(" ", random.Intn(2)+1)) + " | stats count"
+	return reflect.ValueOf(generatedQuery{Query: query, Range: timeRange, Remaining: remaining})
+}
+
+// Tests that the first time modifiers of any base search are extracted and that the rest of the query is kept
+func TestExtractTimeRangeProperties(t *testing.T) {
+	config := &quick.Config{MaxCount: 500}
+
+	extracted := func(generated generatedQuery) bool {
+		timeRange, _ := ExtractTimeRange(generated.Query)
+		return timeRange == generated.Range
+	}
+	if err := quick.Check(extracted, config); err != nil {
+		t.Error("wrong time range:", err)
+	}
+
+	kept := func(generated generatedQuery) bool {
+		_, query := ExtractTimeRange(generated.Query)
+		commands := SplitCommands(query)
+		return reflect.DeepEqual(splitTopLevel(commands[0], func(r rune) bool { return r == ' ' }, true), generated.Remaining) &&
+			strings.HasSuffix(query, "| stats count")
+	}
+	if err := quick.Check(kept, config); err != nil {
+		t.Error("wrong remaining query:", err)
+	}
+
+	idempotent := func(generated generatedQuery) bool {
+		_, query := ExtractTimeRange(generated.Query)
+		timeRange, again := ExtractTimeRange(query)
+		return timeRange == TimeRange{} && again == query
+	}
+	if err := quick.Check(idempotent, config); err != nil {
+		t.Error("time modifiers left in the query:", err)
+	}
+}
@@ -0,0 +1,109 @@
+package spl
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SplitCommands splits the query on the pipes which are not in quotes or in a subsearch
+//
+//	the first part is empty if the query starts with a generating command
+func SplitCommands(query string) []string {
+	return splitTopLevel(query, func(r rune) bool { return r == '|' }, false)
+}
+
+// Normalize returns the query with its commands separated by " | " and their arguments by a single space,
+// the quoted strings and the subsearches being kept as is, so that the same query written differently can be recognized
+func Normalize(query string) string {
+	commands := SplitCommands(query)
+	for i, command := range commands {
+		commands[i] = strings.Join(splitTopLevel(command, unicode.IsSpace, true), " ")
+	}
+	return strings.TrimSpace(strings.Join(commands, " | "))
+}
+
+// split s on the separators which are outside quotes, parentheses, brackets and macros
+//
+//	empty parts are dropped if skipEmpty is set, e.g. when splitting on spaces
+func splitTopLevel(s string, isSeparator func(rune) bool, skipEmpty bool) []string {
+	var parts []string
+	for _, part := range scanTopLevel(s, isSeparator, skipEmpty) {
+		parts = append(parts, s[part.start:part.end])
+	}
+	return parts
+}
+
+// bounds of a part of a string
+type span struct {
+	start int
+	end   int
+}
+
+// return the bounds of the parts of s between the separators which are outside quotes, parentheses, brackets and macros
+func scanTopLevel(s string, isSeparator func(rune) bool, skipEmpty bool) []span {
+	var parts []span
+	var quote rune
+	depth := 0
+	escaped := false
+	start := 0
+
+	add := func(end int) {
+		if !skipEmpty || end > start {
+			parts = append(parts, span{start: start, end: end})
+		}
+	}
+
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case (r == ')' || r == ']') && depth > 0:
+			depth--
+		case depth == 0 && isSeparator(r):
+			add(i)
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	add(len(s))
+
+	return parts
+}
+
+// split the arguments of a command on the spaces and commas outside quotes and parentheses
+func splitArguments(args string) []string {
+	return splitTopLevel(args, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }, true)
+}
+
+// return the index of the first rune of s outside quotes, parentheses and brackets matching the separator, -1 if there is none
+func indexTopLevel(s string, separator rune) int {
+	parts := splitTopLevel(s, func(r rune) bool { return r == separator }, false)
+	if len(parts) < 2 {
+		return -1
+	}
+	return len(parts[0])
+}
+
+// remove the double or single quotes around a name
+func unquote(name string) string {
+	if len(name) < 2 {
+		return name
+	}
+	quote := name[0]
+	if (quote != '"' && quote != '\'') || name[len(name)-1] != quote {
+		return name
+	}
+	name = name[1 : len(name)-1]
+	name = strings.ReplaceAll(name, `\`+string(quote), string(quote))
+	return strings.ReplaceAll(name, `\\`, `\`)
+}
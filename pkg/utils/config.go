@@ -1,5 +1,7 @@
 package utils
 
+import "time"
+
 type EnvConfig struct {
 	// Port on which to listen for cloudevents
 	Port int `envconfig:"RCV_PORT" default:"8080"`
@@ -10,13 +12,76 @@ type EnvConfig struct {
 	// URL of the Keptn configuration service (this is where we can fetch files from the config repo)
 
 	ConfigurationServiceUrl string `envconfig:"CONFIGURATION_SERVICE" default:""`
+	// Port on which the metrics of the service are exposed, disabled if 0
+	MetricsPort int `envconfig:"METRICS_PORT" default:"0"`
 
+	// Url of the REST API of splunk (e.g. https://splunk.example.com:8089/base), used instead of SP_HOST and SP_PORT when set
+	SplunkURL        string `envconfig:"SP_URL" default:""`
 	SplunkApiToken   string `envconfig:"SP_API_TOKEN" default:""`
 	SplunkHost       string `envconfig:"SP_HOST" default:""`
 	SplunkPort       string `envconfig:"SP_PORT" default:"8089"`
 	SplunkUsername   string `envconfig:"SP_USERNAME" default:""`
 	SplunkPassword   string `envconfig:"SP_PASSWORD" default:""`
 	SplunkSessionKey string `envconfig:"SP_SESSION_KEY" default:""`
+	// File or directory (mounted secret) holding the splunk credentials, watched for rotations
+	SplunkCredentialsPath           string        `envconfig:"SP_CREDENTIALS_PATH" default:""`
+	SplunkCredentialsReloadInterval time.Duration `envconfig:"SP_CREDENTIALS_RELOAD_INTERVAL" default:"30s"`
+	// Yaml file describing additional named splunk instances, selectable per project, stage or service
+	SplunkConnectionsFile string `envconfig:"SP_CONNECTIONS_FILE" default:""`
+	// Read the credentials of the projects from the kubernetes secrets splunk-credentials-<project> of POD_NAMESPACE
+	SplunkProjectCredentials bool   `envconfig:"SP_PROJECT_CREDENTIALS" default:"false"`
+	PodNamespace             string `envconfig:"POD_NAMESPACE" default:"keptn"`
+	// Send the username and password on every request instead of logging in for a session key
+	SplunkBasicAuth bool `envconfig:"SP_BASIC_AUTH" default:"false"`
+
+	// TLS settings of the connection to splunk, the certificate of splunk is verified unless SP_SKIP_SSL_VERIFY is set
+	SplunkSkipSSLVerify bool   `envconfig:"SP_SKIP_SSL_VERIFY" default:"false"`
+	SplunkCACert        string `envconfig:"SP_CA_CERT" default:""`
+	SplunkClientCert    string `envconfig:"SP_CLIENT_CERT" default:""`
+	SplunkClientKey     string `envconfig:"SP_CLIENT_KEY" default:""`
+	SplunkTLSServerName string `envconfig:"SP_TLS_SERVER_NAME" default:""`
+	SplunkTLSMinVersion string `envconfig:"SP_TLS_MIN_VERSION" default:"1.2"`
+
+	// Retry policy and circuit breaker applied to the requests sent to splunk
+	SplunkRetryMaxAttempts        int           `envconfig:"SP_RETRY_MAX_ATTEMPTS" default:"3"`
+	SplunkRetryInitialBackoff     time.Duration `envconfig:"SP_RETRY_INITIAL_BACKOFF" default:"500ms"`
+	SplunkRetryMaxBackoff         time.Duration `envconfig:"SP_RETRY_MAX_BACKOFF" default:"10s"`
+	SplunkCircuitBreakerThreshold int           `envconfig:"SP_CIRCUIT_BREAKER_THRESHOLD" default:"5"`
+	SplunkCircuitBreakerCooldown  time.Duration `envconfig:"SP_CIRCUIT_BREAKER_COOLDOWN" default:"30s"`
+
+	// Searches of the SLIs still running after this timeout are cancelled
+	SplunkSLISearchTimeout time.Duration `envconfig:"SP_SLI_SEARCH_TIMEOUT" default:"60s"`
+	// Time zone of the start and end of the evaluations given without time zone, e.g. Europe/Paris
+	SplunkTimezone string `envconfig:"SP_TIMEZONE" default:"UTC"`
+	// Values of the SLIs are reused for the same query, time range and connection during this time, 0 disables the cache
+	SplunkSLICacheTTL time.Duration `envconfig:"SP_SLI_CACHE_TTL" default:"30s"`
+	// What is done when the search of an SLI returns no result (error, zero, default or skip) and the default value of the SLIs
+	SplunkSLINoData             string  `envconfig:"SP_SLI_NO_DATA" default:"error"`
+	SplunkSLINoDataDefaultValue float64 `envconfig:"SP_SLI_NO_DATA_DEFAULT_VALUE" default:"0"`
+
+	// Forwarding of the keptn events to the HTTP Event Collector of splunk, disabled if SP_HEC_URL is empty
+	SplunkHECURL        string `envconfig:"SP_HEC_URL" default:""`
+	SplunkHECToken      string `envconfig:"SP_HEC_TOKEN" default:""`
+	SplunkHECIndex      string `envconfig:"SP_HEC_INDEX" default:""`
+	SplunkHECSourceType string `envconfig:"SP_HEC_SOURCETYPE" default:"keptn:event"`
+	SplunkHECSource     string `envconfig:"SP_HEC_SOURCE" default:"keptn"`
+	SplunkHECEvents     string `envconfig:"SP_HEC_EVENTS" default:"deployment,evaluation,remediation"`
+	// Send the value and the score of the indicators of the evaluations to the metrics index SP_HEC_METRICS_INDEX
+	SplunkHECEvaluationMetrics bool   `envconfig:"SP_HEC_EVALUATION_METRICS" default:"false"`
+	SplunkHECMetricsIndex      string `envconfig:"SP_HEC_METRICS_INDEX" default:""`
+	SplunkHECMetricsSourceType string `envconfig:"SP_HEC_METRICS_SOURCETYPE" default:"keptn:metric"`
+	// Write an annotation event for the deployment.finished and release.finished events, to SP_HEC_INDEX if SP_HEC_ANNOTATIONS_INDEX is empty
+	SplunkHECAnnotations           bool   `envconfig:"SP_HEC_ANNOTATIONS" default:"false"`
+	SplunkHECAnnotationsIndex      string `envconfig:"SP_HEC_ANNOTATIONS_INDEX" default:""`
+	SplunkHECAnnotationsSourceType string `envconfig:"SP_HEC_ANNOTATIONS_SOURCETYPE" default:"keptn:annotation"`
+	// Events are sent by batches of SP_HEC_BATCH_SIZE events or every SP_HEC_FLUSH_INTERVAL
+	SplunkHECBatchSize     int           `envconfig:"SP_HEC_BATCH_SIZE" default:"50"`
+	SplunkHECFlushInterval time.Duration `envconfig:"SP_HEC_FLUSH_INTERVAL" default:"5s"`
+
+	// Namespace of the KV Store collections persisting the state of the service, kept in memory if SP_KVSTORE_APP is empty
+	SplunkKVStoreApp              string `envconfig:"SP_KVSTORE_APP" default:""`
+	SplunkKVStoreOwner            string `envconfig:"SP_KVSTORE_OWNER" default:"nobody"`
+	SplunkKVStoreAlertsCollection string `envconfig:"SP_KVSTORE_ALERTS_COLLECTION" default:"keptn_alerts"`
 
 	AlertSuppressPeriod  string `envconfig:"ALERT_SUPPRESS_PERIOD" default:"3m"`
 	CronSchedule         string `envconfig:"CRON_SCHEDULE" default:"3m"`
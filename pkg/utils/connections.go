@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+
+	logger "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// SplunkConnection is a named splunk instance of the connections file
+type SplunkConnection struct {
+	Name              string `yaml:"name"`
+	SplunkCredentials `yaml:",inline"`
+}
+
+// SplunkConnectionsConfig is the content of the connections file, e.g.
+//
+//	default: nonprod
+//	connections:
+//	  - name: prod
+//	    spHost: splunk-prod.example.com
+//	    spApiToken: ...
+//	  - name: nonprod
+//	    spHost: splunk-dev.example.com
+//	    spUsername: admin
+//	    spPassword: ...
+type SplunkConnectionsConfig struct {
+	// name of the connection used when none is selected, the SP_* environment variables are used if empty
+	Default     string             `yaml:"default"`
+	Connections []SplunkConnection `yaml:"connections"`
+}
+
+// SplunkConnections holds a client for each splunk instance the service can use
+type SplunkConnections struct {
+	clients       map[string]*splunk.SplunkClient
+	defaultClient *splunk.SplunkClient
+}
+
+// create a registry with only a default client
+func NewSplunkConnections(defaultClient *splunk.SplunkClient) *SplunkConnections {
+	return &SplunkConnections{
+		clients:       map[string]*splunk.SplunkClient{},
+		defaultClient: defaultClient,
+	}
+}
+
+// LoadSplunkConnections reads the connections file and creates a client for each connection
+// using the TLS and retry settings of the environment variables
+func LoadSplunkConnections(path string, env EnvConfig, defaultClient *splunk.SplunkClient) (*SplunkConnections, error) {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the splunk connections file %s: %w", path, err)
+	}
+
+	var config SplunkConnectionsConfig
+	err = yaml.Unmarshal(content, &config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid splunk connections file %s: %w", path, err)
+	}
+
+	connections := NewSplunkConnections(defaultClient)
+	for _, connection := range config.Connections {
+		if connection.Name == "" {
+			return nil, fmt.Errorf("invalid splunk connections file %s: a connection has no name", path)
+		}
+		if _, exists := connections.clients[connection.Name]; exists {
+			return nil, fmt.Errorf("invalid splunk connections file %s: connection %s is defined twice", path, connection.Name)
+		}
+		if connection.URL != "" {
+			splunkURL, err := splunk.ParseSplunkURL(connection.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid splunk connections file %s: connection %s: %w", path, connection.Name, err)
+			}
+			connection.Host = splunkURL.Host
+			connection.Port = splunkURL.Port
+		}
+		if connection.Port == "" {
+			connection.Port = env.SplunkPort
+		}
+		if connection.Host == "" || (connection.Token == "" && connection.SessionKey == "" && (connection.Username == "" || connection.Password == "")) {
+			return nil, fmt.Errorf("invalid splunk connections file %s: connection %s needs a host or url and a token, a session key or a username and password", path, connection.Name)
+		}
+
+		client, err := NewSplunkClient(connection.SplunkCredentials, env)
+		if err != nil {
+			return nil, fmt.Errorf("could not create the client of the splunk connection %s: %w", connection.Name, err)
+		}
+		connections.Add(connection.Name, client)
+		logger.Infof("Splunk connection %s loaded (%s)", connection.Name, client.BuildEndpoint(""))
+	}
+
+	if config.Default != "" {
+		client, err := connections.Get(config.Default)
+		if err != nil {
+			return nil, fmt.Errorf("invalid splunk connections file %s: %w", path, err)
+		}
+		connections.defaultClient = client
+	}
+
+	return connections, nil
+}
+
+// add a named client to the registry
+func (c *SplunkConnections) Add(name string, client *splunk.SplunkClient) {
+	c.clients[name] = client
+}
+
+// return the client of the named connection
+func (c *SplunkConnections) Get(name string) (*splunk.SplunkClient, error) {
+	client, ok := c.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("no splunk connection named %s", name)
+	}
+	return client, nil
+}
+
+// return the client used when no connection is selected
+func (c *SplunkConnections) Default() *splunk.SplunkClient {
+	return c.defaultClient
+}
+
+// return the names of the connections in alphabetical order
+func (c *SplunkConnections) Names() []string {
+	names := make([]string, 0, len(c.clients))
+	for name := range c.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// return every distinct client of the registry, the default one first
+func (c *SplunkConnections) Clients() []*splunk.SplunkClient {
+	var clients []*splunk.SplunkClient
+	seen := map[*splunk.SplunkClient]bool{}
+
+	add := func(client *splunk.SplunkClient) {
+		if client != nil && !seen[client] {
+			seen[client] = true
+			clients = append(clients, client)
+		}
+	}
+	add(c.defaultClient)
+	for _, name := range c.Names() {
+		add(c.clients[name])
+	}
+
+	return clients
+}
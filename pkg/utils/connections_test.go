@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+// Tests the LoadSplunkConnections function
+func TestLoadSplunkConnections(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "connections.yaml")
+	err := os.WriteFile(file, []byte(`
+default: nonprod
+connections:
+  - name: prod
+    spHost: splunk-prod.example.com
+    spPort: "8090"
+    spApiToken: prodToken
+  - name: nonprod
+    spHost: splunk-dev.example.com
+    spUsername: admin
+    spPassword: changeme
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envClient := splunk.NewClientAuthenticatedByToken(&http.Client{}, "splunk.example.com", "8089", "token", false)
+	connections, err := LoadSplunkConnections(file, EnvConfig{SplunkPort: "8089", SplunkTLSMinVersion: "1.2"}, envClient)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+
+	prod, err := connections.Get("prod")
+	if err != nil || prod.Host != "splunk-prod.example.com" || prod.Port != "8090" || prod.Token != "prodToken" {
+		t.Fatalf("Unexpected prod connection %+v : %v", prod, err)
+	}
+
+	// the port of the environment is used when none is set and the default connection replaces the environment one
+	if connections.Default().Host != "splunk-dev.example.com" || connections.Default().Port != "8089" || !connections.Default().SessionLogin {
+		t.Fatalf("Unexpected default connection %+v", connections.Default())
+	}
+	if len(connections.Clients()) != 2 || len(connections.Names()) != 2 {
+		t.Fatalf("Expected 2 distinct clients but got %v", len(connections.Clients()))
+	}
+
+	if _, err := connections.Get("unknown"); err == nil {
+		t.Fatal("Expected an error for an unknown connection")
+	}
+}
+
+// Tests that invalid connections files are rejected
+func TestLoadSplunkConnectionsErrors(t *testing.T) {
+	invalidFiles := map[string]string{
+		"no name":         "connections:\n  - spHost: splunk\n    spApiToken: token\n",
+		"no credentials":  "connections:\n  - name: prod\n    spHost: splunk\n",
+		"twice":           "connections:\n  - name: prod\n    spHost: splunk\n    spApiToken: token\n  - name: prod\n    spHost: splunk\n    spApiToken: token\n",
+		"unknown default": "default: other\nconnections:\n  - name: prod\n    spHost: splunk\n    spApiToken: token\n",
+	}
+
+	for name, content := range invalidFiles {
+		file := filepath.Join(t.TempDir(), "connections.yaml")
+		err := os.WriteFile(file, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSplunkConnections(file, EnvConfig{}, nil); err == nil {
+			t.Fatalf("Expected an error for the connections file with %s", name)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+
+	logger "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// names of the files of a mounted kubernetes secret holding the splunk credentials
+var credentialsKeys = []string{"SP_URL", "SP_HOST", "SP_PORT", "SP_API_TOKEN", "SP_USERNAME", "SP_PASSWORD", "SP_SESSION_KEY"}
+
+// ReadSplunkCredentialsFile reads the splunk credentials from a mounted secret
+//
+//	the path can be a directory with one file per key (SP_HOST, SP_API_TOKEN...) as mounted by kubernetes
+//	or a yaml file using the keys spUrl, spHost, spPort, spApiToken, spUsername, spPassword and spSessionKey
+func ReadSplunkCredentialsFile(path string) (*SplunkCredentials, error) {
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not access the splunk credentials at %s: %w", path, err)
+	}
+
+	splunkCreds := SplunkCredentials{}
+	if !info.IsDir() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the splunk credentials file %s: %w", path, err)
+		}
+		err = yaml.Unmarshal(content, &splunkCreds)
+		if err != nil {
+			return nil, fmt.Errorf("invalid splunk credentials file %s: %w", path, err)
+		}
+		return &splunkCreds, nil
+	}
+
+	values := map[string]string{}
+	for _, key := range credentialsKeys {
+		content, err := os.ReadFile(filepath.Join(path, key))
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("could not read the splunk credential %s: %w", key, err)
+		}
+		values[key] = strings.TrimSpace(string(content))
+	}
+	splunkCreds.Host = values["SP_HOST"]
+	splunkCreds.Port = values["SP_PORT"]
+	splunkCreds.Token = values["SP_API_TOKEN"]
+	splunkCreds.Username = values["SP_USERNAME"]
+	splunkCreds.Password = values["SP_PASSWORD"]
+	splunkCreds.SessionKey = values["SP_SESSION_KEY"]
+	splunkCreds.URL = values["SP_URL"]
+
+	return &splunkCreds, nil
+}
+
+// overrides the credentials of the environment with the non empty values read from the credentials file
+func applyCredentialsFile(env EnvConfig, fileCreds *SplunkCredentials) EnvConfig {
+	override := func(value *string, fileValue string) {
+		if fileValue != "" {
+			*value = fileValue
+		}
+	}
+	override(&env.SplunkURL, fileCreds.URL)
+	override(&env.SplunkHost, fileCreds.Host)
+	override(&env.SplunkPort, fileCreds.Port)
+	override(&env.SplunkApiToken, fileCreds.Token)
+	override(&env.SplunkUsername, fileCreds.Username)
+	override(&env.SplunkPassword, fileCreds.Password)
+	override(&env.SplunkSessionKey, fileCreds.SessionKey)
+
+	return env
+}
+
+// returns a hash of the content of the credentials file or directory to detect rotations
+func hashCredentialsPath(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if !info.IsDir() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		h.Write(content)
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	for _, key := range credentialsKeys {
+		content, err := os.ReadFile(filepath.Join(path, key))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		h.Write([]byte(key + "="))
+		h.Write(content)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// check if the credentials point to the same splunk with the same authentication flow, which a running client can not change
+func sameSplunkConnection(current *SplunkCredentials, rotated *SplunkCredentials) bool {
+	sessionLogin := func(c *SplunkCredentials) bool { return c.Token == "" && c.SessionKey == "" && !c.BasicAuth }
+	return current.URL == rotated.URL && current.Host == rotated.Host && current.Port == rotated.Port && sessionLogin(current) == sessionLogin(rotated)
+}
+
+// WatchSplunkCredentials checks the credentials file of the environment every interval and swaps the credentials of the client when it changes
+//
+//	the credentials are merged with the environment variables like at startup, a change of the address of splunk
+//	or of its authentication flow is refused and needs a restart of the service
+func WatchSplunkCredentials(ctx context.Context, env EnvConfig, splunkCreds SplunkCredentials, client *splunk.SplunkClient) {
+
+	path := env.SplunkCredentialsPath
+	current := &splunkCreds
+	lastHash, err := hashCredentialsPath(path)
+	if err != nil {
+		logger.Errorf("Could not read the splunk credentials at %s: %v", path, err)
+	}
+
+	logger.Infof("Watching the splunk credentials at %s every %v", path, env.SplunkCredentialsReloadInterval)
+	ticker := time.NewTicker(env.SplunkCredentialsReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		hash, err := hashCredentialsPath(path)
+		if err != nil {
+			logger.Errorf("Could not read the splunk credentials at %s: %v", path, err)
+			credentialsRotationErrors.Add(1)
+			continue
+		}
+		if hash == lastHash {
+			continue
+		}
+
+		rotated, err := GetSplunkCredentials(env)
+		if err != nil {
+			logger.Errorf("Could not reload the splunk credentials: %v", err)
+			credentialsRotationErrors.Add(1)
+			continue
+		}
+		lastHash = hash
+		if !sameSplunkConnection(current, rotated) {
+			logger.Errorf("Ignoring the new splunk credentials of %s: the address of splunk or its authentication flow changed, restart the service to apply them", path)
+			credentialsRotationErrors.Add(1)
+			continue
+		}
+
+		client.SetCredentials(rotated.Token, rotated.SessionKey, rotated.Username, rotated.Password)
+		current = rotated
+		credentialsRotations.Add(1)
+		credentialsLastRotation.Set(time.Now().Unix())
+		logger.Infof("Splunk credentials reloaded from %s", path)
+	}
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+// writes the credentials the way kubernetes mounts a secret : one file per key
+func writeCredentialsDir(t *testing.T, dir string, values map[string]string) {
+	for key, value := range values {
+		err := os.WriteFile(filepath.Join(dir, key), []byte(value+"\n"), 0600)
+		if err != nil {
+			t.Fatalf("Could not write the credential %s : %v", key, err)
+		}
+	}
+}
+
+// Tests the ReadSplunkCredentialsFile function with a mounted secret and a yaml file
+func TestReadSplunkCredentialsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentialsDir(t, dir, map[string]string{"SP_HOST": "splunk.local", "SP_API_TOKEN": "token1"})
+
+	splunkCreds, err := ReadSplunkCredentialsFile(dir)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if splunkCreds.Host != "splunk.local" || splunkCreds.Token != "token1" || splunkCreds.Port != "" {
+		t.Fatalf("Unexpected credentials read from the directory : %+v", splunkCreds)
+	}
+
+	file := filepath.Join(t.TempDir(), "credentials.yaml")
+	err = os.WriteFile(file, []byte("spHost: splunk.local\nspPort: \"8089\"\nspUsername: admin\nspPassword: changeme\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	splunkCreds, err = ReadSplunkCredentialsFile(file)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if splunkCreds.Port != "8089" || splunkCreds.Username != "admin" || splunkCreds.Password != "changeme" {
+		t.Fatalf("Unexpected credentials read from the file : %+v", splunkCreds)
+	}
+
+	// the values of the file take precedence over the environment variables
+	env := EnvConfig{SplunkHost: "other", SplunkPort: "8090", SplunkApiToken: "envToken", SplunkCredentialsPath: dir}
+	splunkCreds, err = GetSplunkCredentials(env)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if splunkCreds.Host != "splunk.local" || splunkCreds.Port != "8090" || splunkCreds.Token != "token1" {
+		t.Fatalf("Unexpected merged credentials : %+v", splunkCreds)
+	}
+}
+
+// waits until the client sends the expected authorization
+func waitForAuthorization(client *splunk.SplunkClient, expected string) bool {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		token, err := splunk.CreateAuthenticationKey(client)
+		if err == nil && token == expected {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+// Tests that a rotation of the mounted secret is applied to the client
+func TestWatchSplunkCredentials(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentialsDir(t, dir, map[string]string{"SP_HOST": "splunk.local", "SP_API_TOKEN": "token1"})
+
+	env := EnvConfig{SplunkPort: "8089", SplunkCredentialsPath: dir, SplunkCredentialsReloadInterval: 10 * time.Millisecond}
+	splunkCreds, err := GetSplunkCredentials(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, "splunk.local", "8089", "token1", false)
+	rotations := credentialsRotations.Value()
+	rotationErrors := credentialsRotationErrors.Value()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchSplunkCredentials(ctx, env, *splunkCreds, client)
+
+	time.Sleep(50 * time.Millisecond)
+	writeCredentialsDir(t, dir, map[string]string{"SP_API_TOKEN": "token2"})
+
+	if !waitForAuthorization(client, "Bearer token2") {
+		t.Fatal("The new token has not been applied to the client")
+	}
+	if credentialsRotations.Value() != rotations+1 {
+		t.Fatalf("Expected the rotation to be counted once, got %v", credentialsRotations.Value()-rotations)
+	}
+
+	// a new address of splunk is refused
+	writeCredentialsDir(t, dir, map[string]string{"SP_HOST": "splunk.other", "SP_API_TOKEN": "token3"})
+	deadline := time.Now().Add(2 * time.Second)
+	for credentialsRotationErrors.Value() == rotationErrors && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if token, _ := splunk.CreateAuthenticationKey(client); token != "Bearer token2" || client.Host != "splunk.local" {
+		t.Fatalf("Expected the credentials of another splunk to be refused but got %s for %s", token, client.Host)
+	}
+}
+
+// Tests that a rotated password is merged with the username and the authentication flow of the environment
+func TestWatchSplunkCredentialsMergesEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentialsDir(t, dir, map[string]string{"SP_PASSWORD": "password1"})
+
+	env := EnvConfig{
+		SplunkHost: "splunk.local", SplunkPort: "8089", SplunkUsername: "admin", SplunkBasicAuth: true,
+		SplunkCredentialsPath: dir, SplunkCredentialsReloadInterval: 10 * time.Millisecond,
+	}
+	splunkCreds, err := GetSplunkCredentials(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ConnectToSplunk(*splunkCreds, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchSplunkCredentials(ctx, env, *splunkCreds, client)
+
+	time.Sleep(50 * time.Millisecond)
+	writeCredentialsDir(t, dir, map[string]string{"SP_PASSWORD": "password2"})
+
+	if !waitForAuthorization(client, "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:password2"))) {
+		t.Fatal("The rotated password has not been applied with the username of the environment")
+	}
+	if client.SessionLogin {
+		t.Fatal("Expected the client to keep using basic authentication")
+	}
+}
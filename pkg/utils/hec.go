@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/hec"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Creates the batcher sending events to the HTTP Event Collector with the TLS and retry settings of the environment variables
+func NewHECBatcher(env EnvConfig) (*hec.Batcher, error) {
+
+	client, err := hec.NewClient(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		env.SplunkHECURL,
+		env.SplunkHECToken,
+		env.SplunkSkipSSLVerify,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = ConfigureSplunkTLS(client, env)
+	if err != nil {
+		return nil, err
+	}
+	ConfigureSplunkResilience(client, env)
+
+	batcher := hec.NewBatcher(client, env.SplunkHECBatchSize, env.SplunkHECFlushInterval)
+	batcher.OnError = func(err error, events int) {
+		logger.Errorf("Could not send %d events to the splunk HTTP Event Collector: %v", events, err)
+		hecEventsDropped.Add(int64(events))
+	}
+
+	return batcher, nil
+}
+
+// Queues the event in the batcher and counts it
+func ForwardHECEvent(batcher *hec.Batcher, event hec.Event) {
+	batcher.Add(event)
+	hecEventsForwarded.Add(1)
+}
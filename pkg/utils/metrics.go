@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// metrics of the service, published in json on /debug/vars
+var (
+	credentialsRotations      = expvar.NewInt("splunk_credentials_rotations_total")
+	credentialsRotationErrors = expvar.NewInt("splunk_credentials_rotation_errors_total")
+	credentialsLastRotation   = expvar.NewInt("splunk_credentials_last_rotation_timestamp_seconds")
+	hecEventsForwarded        = expvar.NewInt("splunk_hec_events_forwarded_total")
+	hecEventsDropped          = expvar.NewInt("splunk_hec_events_dropped_total")
+	sliCacheHits              = expvar.NewInt("splunk_sli_cache_hits_total")
+	sliCacheMisses            = expvar.NewInt("splunk_sli_cache_misses_total")
+)
+
+// StartMetricsServer exposes the metrics of the service on the given port
+func StartMetricsServer(port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	logger.Infof("Exposing metrics on port %d at /debug/vars", port)
+	err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+	if err != nil {
+		logger.Errorf("Metrics server stopped: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+
+	logger "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// prefix of the kubernetes secrets holding the splunk credentials of a project
+//
+//	only secrets following this naming convention can be read, which keeps the keptn secrets of the namespace out of reach
+const ProjectSecretPrefix = "splunk-credentials-"
+
+// ProjectCredentials creates the splunk clients of the projects bringing their own credentials in a kubernetes secret
+type ProjectCredentials struct {
+	kube      kubernetes.Interface
+	namespace string
+	env       EnvConfig
+
+	mu      sync.Mutex
+	clients map[string]*projectClient
+}
+
+// client created from a secret and the version of the secret it was created from
+type projectClient struct {
+	resourceVersion string
+	client          *splunk.SplunkClient
+}
+
+// create a provider reading the secrets of the namespace with the given kubernetes client
+func NewProjectCredentials(kube kubernetes.Interface, namespace string, env EnvConfig) *ProjectCredentials {
+	return &ProjectCredentials{
+		kube:      kube,
+		namespace: namespace,
+		env:       env,
+		clients:   map[string]*projectClient{},
+	}
+}
+
+// create a provider using the service account of the pod
+func NewInClusterProjectCredentials(env EnvConfig) (*ProjectCredentials, error) {
+
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the kubernetes configuration: %w", err)
+	}
+	kube, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the kubernetes client: %w", err)
+	}
+
+	return NewProjectCredentials(kube, env.PodNamespace, env), nil
+}
+
+// return the name of the secret of the project following the naming convention
+func ProjectSecretName(project string) string {
+	return ProjectSecretPrefix + strings.ToLower(project)
+}
+
+// Client returns the client built from the credentials of the secret, nil if the secret does not exist
+//
+//	the client is cached and its credentials are swapped when the secret is updated
+func (p *ProjectCredentials) Client(ctx context.Context, secretName string) (*splunk.SplunkClient, error) {
+
+	if !strings.HasPrefix(secretName, ProjectSecretPrefix) {
+		return nil, fmt.Errorf("the secret %s does not start with %s", secretName, ProjectSecretPrefix)
+	}
+
+	secret, err := p.kube.CoreV1().Secrets(p.namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the secret %s: %w", secretName, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cached, ok := p.clients[secretName]
+	if ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+
+	values := map[string]string{}
+	for _, key := range credentialsKeys {
+		values[key] = strings.TrimSpace(string(secret.Data[key]))
+	}
+	splunkCreds := SplunkCredentials{
+		Host:       values["SP_HOST"],
+		Port:       values["SP_PORT"],
+		Token:      values["SP_API_TOKEN"],
+		Username:   values["SP_USERNAME"],
+		Password:   values["SP_PASSWORD"],
+		SessionKey: values["SP_SESSION_KEY"],
+		URL:        values["SP_URL"],
+		BasicAuth:  p.env.SplunkBasicAuth,
+	}
+	if splunkCreds.URL != "" {
+		splunkURL, err := splunk.ParseSplunkURL(splunkCreds.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SP_URL in the secret %s: %w", secretName, err)
+		}
+		splunkCreds.Host = splunkURL.Host
+		splunkCreds.Port = splunkURL.Port
+	}
+	if splunkCreds.Port == "" {
+		splunkCreds.Port = p.env.SplunkPort
+	}
+	if splunkCreds.Host == "" || (splunkCreds.Token == "" && splunkCreds.SessionKey == "" && (splunkCreds.Username == "" || splunkCreds.Password == "")) {
+		return nil, fmt.Errorf("the secret %s needs SP_HOST or SP_URL and SP_API_TOKEN, SP_SESSION_KEY or SP_USERNAME and SP_PASSWORD", secretName)
+	}
+
+	// keep the same client when only the credentials changed so the pollers using it pick up the new ones
+	if ok && cached.client.BuildEndpoint("") == clientEndpoint(splunkCreds) {
+		cached.client.SetCredentials(splunkCreds.Token, splunkCreds.SessionKey, splunkCreds.Username, splunkCreds.Password)
+		cached.resourceVersion = secret.ResourceVersion
+		logger.Infof("Splunk credentials reloaded from the secret %s", secretName)
+		return cached.client, nil
+	}
+
+	client, err := NewSplunkClient(splunkCreds, p.env)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the splunk client of the secret %s: %w", secretName, err)
+	}
+	p.clients[secretName] = &projectClient{resourceVersion: secret.ResourceVersion, client: client}
+	logger.Infof("Splunk credentials loaded from the secret %s (%s)", secretName, client.BuildEndpoint(""))
+
+	return client, nil
+}
+
+// return the endpoint a client created from the credentials would use
+func clientEndpoint(splunkCreds SplunkCredentials) string {
+	client := &splunk.SplunkClient{Host: splunkCreds.Host, Port: splunkCreds.Port}
+	if splunkCreds.URL != "" {
+		_ = client.SetURL(splunkCreds.URL)
+	}
+	return client.BuildEndpoint("")
+}
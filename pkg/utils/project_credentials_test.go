@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Tests that the clients of the project secrets are cached and follow the updates of the secrets
+func TestProjectCredentialsClient(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: ProjectSecretName("FullTour"), Namespace: "keptn", ResourceVersion: "1"},
+		Data:       map[string][]byte{"SP_HOST": []byte("splunk"), "SP_PORT": []byte("8090"), "SP_API_TOKEN": []byte("token1")},
+	}
+	kube := fake.NewSimpleClientset(secret)
+	credentials := NewProjectCredentials(kube, "keptn", EnvConfig{SplunkPort: "8089", SplunkTLSMinVersion: "1.2"})
+	ctx := context.Background()
+
+	client, err := credentials.Client(ctx, "splunk-credentials-fulltour")
+	if err != nil || client == nil || client.Port != "8090" || client.Token != "token1" {
+		t.Fatalf("Unexpected client %v : %v", client, err)
+	}
+
+	// a rotated token is applied to the same client
+	secret.ResourceVersion = "2"
+	secret.Data["SP_API_TOKEN"] = []byte("token2")
+	_, err = kube.CoreV1().Secrets("keptn").Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := credentials.Client(ctx, "splunk-credentials-fulltour")
+	if err != nil || rotated != client || client.Token != "token2" {
+		t.Fatalf("Expected the token of the client to be rotated but got %v : %v", rotated, err)
+	}
+
+	missing, err := credentials.Client(ctx, "splunk-credentials-other")
+	if err != nil || missing != nil {
+		t.Fatalf("Expected no client for a missing secret but got %v : %v", missing, err)
+	}
+
+	// only the secrets following the naming convention can be read
+	if _, err := credentials.Client(ctx, "keptn-api-token"); err == nil {
+		t.Fatal("Expected an error for a secret outside of the naming convention")
+	}
+}
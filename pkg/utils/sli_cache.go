@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
+)
+
+// SLICache keeps the values of the SLI searches for a short time and runs the identical searches requested
+// concurrently only once, so that the SLIs sharing a query and the retried evaluations do not search splunk again
+type SLICache struct {
+	ttl time.Duration
+	// replaced in the tests
+	now func() time.Time
+
+	mu       sync.Mutex
+	values   map[string]cachedValue
+	searches map[string]*runningSearch
+}
+
+// value of a search and the time until which it can be reused
+type cachedValue struct {
+	value   float64
+	expires time.Time
+}
+
+// errSearchPanicked is returned to the callers waiting for a search which panicked
+var errSearchPanicked = errors.New("the shared SLI search panicked")
+
+// search shared by the callers requesting it while it runs
+type runningSearch struct {
+	done  chan struct{}
+	value float64
+	err   error
+}
+
+// create a cache keeping the values for ttl, the values are not kept if ttl is 0 but the concurrent searches are still shared
+func NewSLICache(ttl time.Duration) *SLICache {
+	return &SLICache{
+		ttl:      ttl,
+		now:      time.Now,
+		values:   map[string]cachedValue{},
+		searches: map[string]*runningSearch{},
+	}
+}
+
+// SLICacheKey identifies a search by its connection, its time range, the field its value is read from and its normalized query
+//
+//	the clients are kept for the lifetime of their connection, a client created for new credentials gets its own entries
+func SLICacheKey(client *splunk.SplunkClient, query string, resultField string, earliestTime string, latestTime string) string {
+	return fmt.Sprintf("%p|%s|%s|%s|%s", client, earliestTime, latestTime, resultField, spl.Normalize(query))
+}
+
+// Get returns the value cached for the key or the value of search, which is run once for the concurrent callers
+//
+//	the errors are not cached, the next call runs the search again
+func (c *SLICache) Get(key string, search func() (float64, error)) (float64, error) {
+	c.mu.Lock()
+	now := c.now()
+	if cached, ok := c.values[key]; ok && now.Before(cached.expires) {
+		c.mu.Unlock()
+		sliCacheHits.Add(1)
+		return cached.value, nil
+	}
+	if running, ok := c.searches[key]; ok {
+		c.mu.Unlock()
+		sliCacheHits.Add(1)
+		<-running.done
+		return running.value, running.err
+	}
+	// the error is kept if the search panics, so that its waiters are released with it
+	running := &runningSearch{done: make(chan struct{}), value: -1, err: errSearchPanicked}
+	c.searches[key] = running
+	c.mu.Unlock()
+	sliCacheMisses.Add(1)
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.searches, key)
+		if running.err == nil && c.ttl > 0 {
+			c.removeExpired(now)
+			c.values[key] = cachedValue{value: running.value, expires: c.now().Add(c.ttl)}
+		}
+		c.mu.Unlock()
+		close(running.done)
+	}()
+
+	running.value, running.err = search()
+	return running.value, running.err
+}
+
+// remove the expired values, the lock must be held
+func (c *SLICache) removeExpired(now time.Time) {
+	for key, cached := range c.values {
+		if !now.Before(cached.expires) {
+			delete(c.values, key)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+)
+
+// Tests that the values are reused until they expire and that the errors are not cached
+func TestSLICacheExpiration(t *testing.T) {
+	now := time.Now()
+	cache := NewSLICache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	searches := 0
+	search := func() (float64, error) {
+		searches++
+		return float64(searches), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		value, err := cache.Get("errors", search)
+		if err != nil || value != 1 {
+			t.Fatalf("Expected the cached value 1 but got %v : %v", value, err)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	value, err := cache.Get("errors", search)
+	if err != nil || value != 2 {
+		t.Fatalf("Expected the expired value to be searched again but got %v : %v", value, err)
+	}
+
+	failures := 0
+	failing := func() (float64, error) {
+		failures++
+		return 0, fmt.Errorf("search failed")
+	}
+	_, _ = cache.Get("failing", failing)
+	_, err = cache.Get("failing", failing)
+	if err == nil || failures != 2 {
+		t.Fatalf("Expected the failed search to be run again, got %d searches : %v", failures, err)
+	}
+}
+
+// Tests that the identical searches requested concurrently are run once, even without caching the values
+func TestSLICacheConcurrentSearches(t *testing.T) {
+	cache := NewSLICache(0)
+
+	var searches int32
+	release := make(chan struct{})
+	search := func() (float64, error) {
+		atomic.AddInt32(&searches, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]float64, 10)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], _ = cache.Get("latency", search)
+		}(i)
+	}
+
+	// let the callers wait for the running search
+	for {
+		cache.mu.Lock()
+		_, running := cache.searches["latency"]
+		cache.mu.Unlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&searches) != 1 {
+		t.Fatalf("Expected one search but got %d", searches)
+	}
+	for _, value := range values {
+		if value != 42 {
+			t.Fatalf("Expected the shared value 42 but got %v", values)
+		}
+	}
+
+	// the value is not kept once the search is done
+	_, _ = cache.Get("latency", func() (float64, error) { atomic.AddInt32(&searches, 1); return 0, nil })
+	if atomic.LoadInt32(&searches) != 2 {
+		t.Fatal("Expected the value not to be cached")
+	}
+}
+
+// Tests that the callers waiting for a search which panics are released and that the next call searches again
+func TestSLICachePanickingSearch(t *testing.T) {
+	cache := NewSLICache(time.Minute)
+
+	release := make(chan struct{})
+	go func() {
+		defer func() { _ = recover() }()
+		_, _ = cache.Get("errors", func() (float64, error) {
+			<-release
+			panic("search failed")
+		})
+	}()
+
+	// let the search run before waiting for it
+	for {
+		cache.mu.Lock()
+		_, running := cache.searches["errors"]
+		cache.mu.Unlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	waiter := make(chan error)
+	go func() {
+		_, err := cache.Get("errors", func() (float64, error) { return 0, nil })
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-waiter:
+		if !errors.Is(err, errSearchPanicked) {
+			t.Fatalf("Expected the waiter to get errSearchPanicked but got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("The waiter of the panicking search is still blocked")
+	}
+
+	value, err := cache.Get("errors", func() (float64, error) { return 3, nil })
+	if err != nil || value != 3 {
+		t.Fatalf("Expected the search to be run again but got %v : %v", value, err)
+	}
+}
+
+// Tests that the key identifies the connection, the time range, the result field and the normalized query
+func TestSLICacheKey(t *testing.T) {
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, "localhost", "8089", "token", false)
+	other := splunk.NewClientAuthenticatedByToken(&http.Client{}, "localhost", "8089", "token", false)
+
+	key := SLICacheKey(client, "index=main  status=500 |stats count", "count", "-5m", "now")
+	if SLICacheKey(client, " index=main status=500 | stats   count ", "count", "-5m", "now") != key {
+		t.Error("Expected the same key for the same query written differently")
+	}
+	if SLICacheKey(client, `index=main status="500  " | stats count`, "count", "-5m", "now") == key {
+		t.Error("Expected a different key for a different quoted value")
+	}
+	if SLICacheKey(client, "index=main status=500 | stats count", "count", "-10m", "now") == key {
+		t.Error("Expected a different key for a different time range")
+	}
+	if SLICacheKey(client, "index=main status=500 | stats count", "total", "-5m", "now") == key {
+		t.Error("Expected a different key for a different result field")
+	}
+	if SLICacheKey(other, "index=main status=500 | stats count", "count", "-5m", "now") == key {
+		t.Error("Expected a different key for a different connection")
+	}
+}
@@ -20,12 +20,34 @@ type SplunkCredentials struct {
 	Password   string `json:"password" yaml:"spPassword"`
 	Token      string `json:"token" yaml:"spApiToken"`
 	SessionKey string `json:"sessionKey" yaml:"spSessionKey"`
+	// url of the REST API, overrides the host and the port when set
+	URL string `json:"url" yaml:"spUrl"`
+	// if true, basic authentication is used instead of the session-key login flow
+	BasicAuth bool `json:"basicAuth" yaml:"spBasicAuth"`
 }
 
 // getSplunkCredentials get the splunk host, port and api token from the environment variables set from secret
 func GetSplunkCredentials(env EnvConfig) (*SplunkCredentials, error) {
 
 	logger.Info("Trying to retrieve splunk credentials ...")
+	if env.SplunkCredentialsPath != "" {
+		fileCreds, err := ReadSplunkCredentialsFile(env.SplunkCredentialsPath)
+		if err != nil {
+			return nil, err
+		}
+		env = applyCredentialsFile(env, fileCreds)
+	}
+
+	if env.SplunkURL != "" {
+		splunkURL, err := splunk.ParseSplunkURL(env.SplunkURL)
+		if err != nil {
+			return nil, err
+		}
+		// the port of the url, or of its scheme, takes precedence over SP_PORT
+		env.SplunkHost = splunkURL.Host
+		env.SplunkPort = splunkURL.Port
+	}
+
 	splunkCreds := SplunkCredentials{}
 	switch {
 	case env.SplunkHost != "" && env.SplunkPort != "" && (env.SplunkApiToken != "" || (env.SplunkUsername != "" && env.SplunkPassword != "") || env.SplunkSessionKey != ""):
@@ -35,12 +57,14 @@ func GetSplunkCredentials(env EnvConfig) (*SplunkCredentials, error) {
 		splunkCreds.Username = env.SplunkUsername
 		splunkCreds.Password = env.SplunkPassword
 		splunkCreds.SessionKey = env.SplunkSessionKey
+		splunkCreds.BasicAuth = env.SplunkBasicAuth
+		splunkCreds.URL = env.SplunkURL
 
 		logger.Info("Successfully retrieved splunk credentials")
 
 	default:
 		if env.SplunkHost == "" {
-			logger.Error("SP_HOST not set")
+			logger.Error("SP_HOST or SP_URL not set")
 		}
 		if env.SplunkPort == "" {
 			logger.Error("SP_PORT not set")
@@ -86,7 +110,7 @@ func ConnectToSplunk(splunkCreds SplunkCredentials, skipSSL bool) *splunk.Splunk
 			splunkCreds.SessionKey,
 			skipSSL,
 		)
-	default:
+	case splunkCreds.BasicAuth:
 		client = splunk.NewBasicAuthenticatedClient(
 			&http.Client{
 				Timeout: time.Duration(60) * time.Second,
@@ -97,11 +121,84 @@ func ConnectToSplunk(splunkCreds SplunkCredentials, skipSSL bool) *splunk.Splunk
 			splunkCreds.Password,
 			skipSSL,
 		)
+	default:
+		client = splunk.NewClientAuthenticatedByLogin(
+			&http.Client{
+				Timeout: time.Duration(60) * time.Second,
+			},
+			splunkCreds.Host,
+			splunkCreds.Port,
+			splunkCreds.Username,
+			splunkCreds.Password,
+			skipSSL,
+		)
+	}
+
+	if splunkCreds.URL != "" {
+		err := client.SetURL(splunkCreds.URL)
+		if err != nil {
+			logger.Errorf("Ignoring the splunk url: %v", err)
+		}
 	}
 
 	return client
 }
 
+// Creates a client for the given credentials with the TLS and retry settings of the environment variables
+func NewSplunkClient(splunkCreds SplunkCredentials, env EnvConfig) (*splunk.SplunkClient, error) {
+
+	if splunkCreds.URL != "" {
+		_, err := splunk.ParseSplunkURL(splunkCreds.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := ConnectToSplunk(splunkCreds, env.SplunkSkipSSLVerify)
+	err := ConfigureSplunkTLS(client, env)
+	if err != nil {
+		return nil, err
+	}
+	ConfigureSplunkResilience(client, env)
+
+	return client, nil
+}
+
+// Applies the TLS settings of the environment variables to the client
+func ConfigureSplunkTLS(client *splunk.SplunkClient, env EnvConfig) error {
+
+	if env.SplunkSkipSSLVerify {
+		logger.Warn("SP_SKIP_SSL_VERIFY is set, the certificate of splunk is not verified")
+	}
+
+	return splunk.ConfigureTLS(client, GetSplunkTLSOptions(env))
+}
+
+// Returns the TLS options set in the environment variables
+func GetSplunkTLSOptions(env EnvConfig) splunk.TLSOptions {
+	return splunk.TLSOptions{
+		CACertPath:         env.SplunkCACert,
+		ClientCertPath:     env.SplunkClientCert,
+		ClientKeyPath:      env.SplunkClientKey,
+		ServerName:         env.SplunkTLSServerName,
+		MinVersion:         env.SplunkTLSMinVersion,
+		InsecureSkipVerify: env.SplunkSkipSSLVerify,
+	}
+}
+
+// Sets the retry policy and the circuit breaker of the client from the environment variables
+func ConfigureSplunkResilience(client *splunk.SplunkClient, env EnvConfig) {
+
+	if env.SplunkRetryMaxAttempts > 1 {
+		client.RetryPolicy = splunk.NewRetryPolicy(env.SplunkRetryMaxAttempts, env.SplunkRetryInitialBackoff, env.SplunkRetryMaxBackoff)
+		logger.Infof("Requests to splunk are retried up to %d times", env.SplunkRetryMaxAttempts)
+	}
+	if env.SplunkCircuitBreakerThreshold > 0 {
+		client.CircuitBreaker = splunk.NewCircuitBreaker(env.SplunkCircuitBreakerThreshold, env.SplunkCircuitBreakerCooldown)
+		logger.Infof("Circuit breaker opens after %d consecutive failures for %v", env.SplunkCircuitBreakerThreshold, env.SplunkCircuitBreakerCooldown)
+	}
+}
+
 // Build a mock splunk server returning default responses when getting  get and post requests
 func BuildMockSplunkServer(splunkResult float64) *httptest.Server {
 
@@ -115,8 +212,12 @@ func BuildMockSplunkServer(splunkResult float64) *httptest.Server {
 	splunkResponses[0] = map[string]interface{}{
 		http.MethodPost: jsonResponsePOST,
 	}
+	jsonResponseJobStatus := `{
+		"entry":[{"content":{"sid":"10","dispatchState":"DONE","isDone":true,"resultCount":1}}]
+	}`
 	splunkResponses[1] = map[string]interface{}{
-		http.MethodGet: jsonResponseGET,
+		splunktest.GetJobStatus: jsonResponseJobStatus,
+		http.MethodGet:          jsonResponseGET,
 	}
 	splunkServer := splunktest.MultitpleMockRequest(splunkResponses, true)
 
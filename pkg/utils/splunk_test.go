@@ -32,3 +32,36 @@ func TestGetSplunkCredentials(t *testing.T) {
 		t.Logf("Received expected error : %v", err)
 	}
 }
+
+// Tests that SP_URL replaces SP_HOST and SP_PORT
+func TestGetSplunkCredentialsFromURL(t *testing.T) {
+	env := EnvConfig{SplunkURL: "http://[::1]:8000/splunk", SplunkPort: "8089", SplunkApiToken: "token", SplunkTLSMinVersion: "1.2"}
+
+	sp, err := GetSplunkCredentials(env)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if sp.Host != "::1" || sp.Port != "8000" {
+		t.Fatalf("Unexpected credentials %+v", sp)
+	}
+
+	client, err := NewSplunkClient(*sp, env)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	if endpoint := client.BuildEndpoint("services"); endpoint != "http://[::1]:8000/splunk/services" {
+		t.Fatalf("Unexpected endpoint %s", endpoint)
+	}
+
+	// the port of the scheme is used when the url has none, not SP_PORT
+	env.SplunkURL = "https://splunk.example.com/base"
+	sp, err = GetSplunkCredentials(env)
+	if err != nil || sp.Host != "splunk.example.com" || sp.Port != "443" {
+		t.Fatalf("Expected the port of https but got %+v : %v", sp, err)
+	}
+
+	env.SplunkURL = "ftp://splunk"
+	if _, err := GetSplunkCredentials(env); err == nil {
+		t.Fatal("Expected an error for an invalid url")
+	}
+}
@@ -1,46 +1,97 @@
 package utils
 
 import (
-	"strings"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/spl"
 )
 
-// check if the search string contains the earliest or latest time and return the time and the query
-func getQueryTime(kind string, searchQuery string, defaultTime string) (string, string) {
-	if !strings.Contains(searchQuery, kind) {
-		return defaultTime, searchQuery
+// layouts of the times without time zone, read in the given location
+var localTimeLayouts = []string{"2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+// get the earliest, latest time from the splunk search and also update the search query
+//
+//	the time modifiers of the query take precedence over the given times, see spl.ExtractTimeRange
+func RetrieveQueryTimeRange(earliestTime string, latestTime string, searchQuery string) (string, string, string) {
+
+	timeRange, searchQuery := spl.ExtractTimeRange(searchQuery)
+	if timeRange.Earliest != "" {
+		earliestTime = timeRange.Earliest
+	}
+	if timeRange.Latest != "" {
+		latestTime = timeRange.Latest
 	}
-	startIndex := strings.Index(searchQuery, kind)
-	q1 := strings.Fields(searchQuery[startIndex:])
 
-	timeValue := ""
-	if !strings.HasPrefix(q1[0][len(kind)+1:], "\"") {
-		timeValue = q1[0][len(kind)+1:]
-		searchQuery = strings.ReplaceAll(searchQuery, q1[0], "")
+	return earliestTime, latestTime, searchQuery
+}
 
-		return strings.TrimSuffix(timeValue, "\""), searchQuery
+// ParseISOTime parses an ISO-8601 time like the start and end of the keptn events (e.g. 2022-07-05T12:31:40.000Z),
+// the times without time zone are read in location
+func ParseISOTime(value string, location *time.Location) (time.Time, bool) {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err == nil {
+		return parsed, true
 	}
-	for i, v := range q1 {
-		switch i {
-		case 0:
-			timeValue += v[len(kind)+2:]
-		default:
-			timeValue += " " + v
-		}
-		if strings.HasSuffix(v, "\"") {
-			break
+	for _, layout := range localTimeLayouts {
+		parsed, err = time.ParseInLocation(layout, value, location)
+		if err == nil {
+			return parsed, true
 		}
 	}
-	searchQuery = strings.ReplaceAll(searchQuery, timeValue, "")
-	searchQuery = strings.ReplaceAll(searchQuery, kind+"=\"", "")
+	return time.Time{}, false
+}
 
-	return strings.TrimSuffix(timeValue, "\""), searchQuery
+// FormatEpochTime returns the epoch time of t with its milliseconds, read by splunk whatever its time_format
+func FormatEpochTime(t time.Time) string {
+	millis := t.UnixMilli()
+	if millis%1000 == 0 {
+		return fmt.Sprintf("%d", millis/1000)
+	}
+	return fmt.Sprintf("%d.%03d", millis/1000, millis%1000)
 }
 
-// get the earliest, latest time from the splunk search and also update the search query
-func RetrieveQueryTimeRange(earliestTime string, latestTime string, searchQuery string) (string, string, string) {
+// ToSplunkTimeRange converts the ISO-8601 start and end of a keptn event to epoch times moved back by offset
+//
+//	the times without time zone are read in location and the other values, like the relative time -5m@m, are moved back
+//	by chaining the offset to them (-5m@m-120s). An error is returned if the start is not before the end
+func ToSplunkTimeRange(start string, end string, offset time.Duration, location *time.Location) (string, string, error) {
+	startTime, startIsAbsolute := ParseISOTime(start, location)
+	endTime, endIsAbsolute := ParseISOTime(end, location)
 
-	earliestTime, searchQuery = getQueryTime("earliest", searchQuery, earliestTime)
-	latestTime, searchQuery = getQueryTime("latest", searchQuery, latestTime)
+	if startIsAbsolute && endIsAbsolute && !startTime.Before(endTime) {
+		return "", "", fmt.Errorf("the start %s of the time range is not before its end %s", start, end)
+	}
 
-	return earliestTime, latestTime, searchQuery
+	if startIsAbsolute {
+		start = FormatEpochTime(startTime.Add(-offset))
+	} else {
+		start = offsetSplunkTime(start, offset)
+	}
+	if endIsAbsolute {
+		end = FormatEpochTime(endTime.Add(-offset))
+	} else {
+		end = offsetSplunkTime(end, offset)
+	}
+	return start, end, nil
+}
+
+// move an epoch or a relative time of splunk back by offset, the empty time and 0 (all time) are kept
+func offsetSplunkTime(value string, offset time.Duration) string {
+	if offset == 0 || value == "" || value == "0" {
+		return value
+	}
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil {
+		return FormatEpochTime(time.UnixMilli(int64(epoch * 1000)).Add(-offset))
+	}
+
+	modifier := fmt.Sprintf("-%ds", offset/time.Second)
+	if offset%time.Second != 0 {
+		modifier = fmt.Sprintf("-%dms", offset.Milliseconds())
+	}
+	if value == "now" {
+		return modifier
+	}
+	return value + modifier
 }
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	splunkjob "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/jobs"
 )
@@ -36,6 +37,13 @@ func TestRetrieveSearchTimeRange(t *testing.T) {
 	splunkRequestParams.SearchQuery = "source=/opt/splunk/var/log/secure.log sourcetype=osx_secure earliest=" + earliestTimeInRequest + " earliest=" + earliestTimeInParams + " |stats count"
 	checkRetrieveSearchTimeRange(t, splunkRequestParams, earliestTimeInParams, latestTimeInParams, earliestTimeInRequest, latestTimeInParams)
 
+	//Verify if the function ignores the fields named like a time modifier and keeps them in the query
+	splunkRequestParams.SearchQuery = "source=/opt/splunk/var/log/secure.log earliest_login=" + earliestTimeInRequest + " |stats count"
+	checkRetrieveSearchTimeRange(t, splunkRequestParams, earliestTimeInParams, latestTimeInParams, earliestTimeInParams, latestTimeInParams)
+	if splunkRequestParams.SearchQuery != "source=/opt/splunk/var/log/secure.log earliest_login="+earliestTimeInRequest+" |stats count" {
+		t.Fatalf("The query should not be modified but got %s", splunkRequestParams.SearchQuery)
+	}
+
 }
 
 // checks if we have the expected parameters in the final request sent to splunk
@@ -58,3 +66,43 @@ func checkRetrieveSearchTimeRange(t *testing.T, splunkRequestParams *splunkjob.S
 	}
 
 }
+
+// Tests the conversion of the time range of the keptn events to epoch times
+func TestToSplunkTimeRange(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+
+	tests := []struct {
+		start, end                 string
+		offset                     time.Duration
+		location                   *time.Location
+		expectedStart, expectedEnd string
+	}{
+		{"2022-07-05T12:26:40.000Z", "2022-07-05T12:31:40.000Z", 0, time.UTC, "1657024000", "1657024300"},
+		{"2022-07-05T12:26:40.250Z", "2022-07-05T14:31:40+02:00", 0, time.UTC, "1657024000.250", "1657024300"},
+		{"2022-07-05T12:26:40.000Z", "2022-07-05T12:31:40.000Z", 2 * time.Minute, time.UTC, "1657023880", "1657024180"},
+		// times without time zone are read in the given location
+		{"2022-07-05T14:26:40", "2022-07-05 14:31:40", 0, paris, "1657024000", "1657024300"},
+		// the offset is chained to the relative times
+		{"-5m@m", "now", time.Minute, time.UTC, "-5m@m-60s", "-60s"},
+		{"-5m@m", "now", 0, time.UTC, "-5m@m", "now"},
+		{"-1h", "-5m", 1500 * time.Millisecond, time.UTC, "-1h-1500ms", "-5m-1500ms"},
+		// epoch times are moved back and all time is kept
+		{"0", "1657024300", 2 * time.Minute, time.UTC, "0", "1657024180"},
+		{"", "", time.Minute, time.UTC, "", ""},
+	}
+
+	for _, test := range tests {
+		start, end, err := ToSplunkTimeRange(test.start, test.end, test.offset, test.location)
+		if err != nil || start != test.expectedStart || end != test.expectedEnd {
+			t.Errorf("ToSplunkTimeRange(%s, %s, %v) = %s, %s, %v, expected %s, %s", test.start, test.end, test.offset, start, end, err, test.expectedStart, test.expectedEnd)
+		}
+	}
+
+	_, _, err = ToSplunkTimeRange("2022-07-05T12:31:40.000Z", "2022-07-05T12:31:40.000Z", 0, time.UTC)
+	if err == nil {
+		t.Error("Expected an error for an empty time range")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ECL2022PAI01/splunk-service/handler"
+	splunk "github.com/ECL2022PAI01/splunk-service/pkg/splunksdk/client"
+	"github.com/ECL2022PAI01/splunk-service/pkg/utils"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// layout of the times of the keptn events
+const keptnTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// Runs the preview subcommand, printing the SLIs and the alerts of local sli.yaml and slo.yaml files
+//
+//	splunk-service preview --sli sli.yaml --slo slo.yaml --stage prod [--execute]
+func runPreview(args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	flags.SetOutput(w)
+	options := handler.PreviewOptions{}
+	flags.StringVar(&options.SLIFile, "sli", "", "sli.yaml file of the indicators (required)")
+	flags.StringVar(&options.SLOFile, "slo", "", "slo.yaml file of the objectives, the alerts are previewed if set")
+	flags.StringVar(&options.Project, "project", "project", "project of the alerts")
+	flags.StringVar(&options.Stage, "stage", "stage", "stage of the alerts")
+	flags.StringVar(&options.Service, "service", "service", "service of the alerts")
+	flags.StringVar(&options.Start, "start", "", "start of the evaluation, 5 minutes before the end by default")
+	flags.StringVar(&options.End, "end", "", "end of the evaluation, now by default")
+	flags.BoolVar(&options.Execute, "execute", false, "run the SLIs in splunk, with the credentials of the environment")
+	connection := flags.String("connection", "", "connection of SP_CONNECTIONS_FILE the SLIs are run on, the splunk of the environment by default")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if options.SLIFile == "" {
+		flags.Usage()
+		return fmt.Errorf("the sli file is required")
+	}
+
+	// only the result of the preview is written
+	logger.SetLevel(logger.WarnLevel)
+	configureSLIs()
+
+	// the evaluation covers the 5 minutes before its end, now by default
+	end := time.Now().UTC()
+	if options.End == "" {
+		options.End = end.Format(keptnTimeLayout)
+	} else if options.Start == "" {
+		// the time zone is checked by configureSLIs
+		location, _ := time.LoadLocation(env.SplunkTimezone)
+		var ok bool
+		end, ok = utils.ParseISOTime(options.End, location)
+		if !ok {
+			return fmt.Errorf("the start is required with the end %s, which is not an ISO-8601 time", options.End)
+		}
+	}
+	if options.Start == "" {
+		options.Start = end.Add(-5 * time.Minute).UTC().Format(keptnTimeLayout)
+	}
+
+	var client *splunk.SplunkClient
+	if options.Execute {
+		client, err = connectSplunk(*connection)
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler.Preview(w, options, env, client)
+}
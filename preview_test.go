@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Tests that the default time range of the preview ends at the given end
+func TestRunPreviewDefaultStart(t *testing.T) {
+	sliFile := filepath.Join(t.TempDir(), "sli.yaml")
+	err := os.WriteFile(sliFile, []byte("spec_version: \"1.0\"\nindicators:\n  number_of_errors: index=main \"[error]\" | stats count\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	err = runPreview([]string{"--sli", sliFile, "--end", "2022-07-05T12:31:40.000Z"}, &output)
+	if err != nil {
+		t.Fatalf("Error while previewing the SLIs : %v", err)
+	}
+	if !strings.Contains(output.String(), "SLIs from 2022-07-05T12:26:40.000Z to 2022-07-05T12:31:40.000Z") ||
+		!strings.Contains(output.String(), "earliest: 1657024000") || strings.Contains(output.String(), "invalid time range") {
+		t.Fatalf("Expected the 5 minutes before the end but got :\n%s", output.String())
+	}
+
+	err = runPreview([]string{"--sli", sliFile, "--end", "-1h"}, &output)
+	if err == nil {
+		t.Fatal("Expected an error for a relative end without start")
+	}
+}